@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,35 +15,49 @@ import (
 	_ "github.com/jackc/pgx/v5/stdlib"
 
 	"service-timetable/internal/app"
+	"service-timetable/internal/auth"
+	"service-timetable/internal/logging"
+	"service-timetable/internal/service"
+	"service-timetable/internal/tracing"
 	servicemigrations "service-timetable/migrations"
 )
 
 func main() {
-	logger := log.New(os.Stdout, "", log.LstdFlags|log.LUTC)
-
 	config, err := loadConfig()
 	if err != nil {
-		logger.Fatalf("config error: %v", err)
+		fatal("config error", err)
 	}
 
-	debugEnabled := strings.EqualFold(strings.TrimSpace(config.LogLevel), "debug")
-	debugf := func(format string, args ...any) {
-		if debugEnabled {
-			logger.Printf("[DEBUG] "+format, args...)
-		}
+	logger, err := logging.New(os.Stdout, config.LogLevel)
+	if err != nil {
+		fatal("config error", err)
 	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TracingExporter)
+	if err != nil {
+		fatal("failed to initialize tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("tracing shutdown error", "error", err)
+		}
+	}()
 
-	debugf("config loaded: http_addr=%s identity_base_url=%s db_max_open=%d db_max_idle=%d db_conn_max_lifetime=%s",
-		config.HTTPAddr,
-		config.IdentityBaseURL,
-		config.DBMaxOpenConns,
-		config.DBMaxIdleConns,
-		config.DBConnMaxLifetime,
+	slog.Debug("config loaded",
+		"http_addr", config.HTTPAddr,
+		"identity_provider", config.IdentityProvider,
+		"identity_base_url", config.IdentityBaseURL,
+		"db_max_open", config.DBMaxOpenConns,
+		"db_max_idle", config.DBMaxIdleConns,
+		"db_conn_max_lifetime", config.DBConnMaxLifetime.String(),
 	)
 
 	db, err := sql.Open("pgx", config.DatabaseURL)
 	if err != nil {
-		logger.Fatalf("failed to open database: %v", err)
+		fatal("failed to open database", err)
 	}
 	defer db.Close()
 
@@ -52,20 +66,57 @@ func main() {
 	db.SetConnMaxLifetime(config.DBConnMaxLifetime)
 
 	if err := db.Ping(); err != nil {
-		logger.Fatalf("failed to connect to database: %v", err)
+		fatal("failed to connect to database", err)
 	}
-	debugf("database connection successful")
+	slog.Debug("database connection successful")
 
 	if err := servicemigrations.Up(db); err != nil {
-		logger.Fatalf("failed to run migrations: %v", err)
+		fatal("failed to run migrations", err)
 	}
-	debugf("migrations completed successfully")
+	slog.Debug("migrations completed successfully")
 
-	application := app.New(db, config.IdentityBaseURL)
+	authenticator, err := newAuthenticator(config)
+	if err != nil {
+		fatal("failed to initialize authentication", err)
+	}
+	if authenticator.Mode() == auth.ModeTrustedGateway {
+		slog.Warn("trusting X-User-ID, only run behind an authenticating gateway", "auth_mode", auth.ModeTrustedGateway)
+	}
+
+	application, err := app.New(db, app.Config{
+		IdentityProvider:         config.IdentityProvider,
+		IdentityStaticFile:       config.IdentityStaticFile,
+		IdentityBaseURL:          config.IdentityBaseURL,
+		IdentityTimeout:          config.IdentityTimeout,
+		IdentityMaxRetries:       config.IdentityMaxRetries,
+		IdentityRetryBaseDelay:   config.IdentityRetryBaseDelay,
+		IdentityRetryMaxDelay:    config.IdentityRetryMaxDelay,
+		IdentityBreakerFailures:  config.IdentityBreakerFailures,
+		IdentityBreakerCooldown:  config.IdentityBreakerCooldown,
+		IdentityCacheTTL:         config.IdentityCacheTTL,
+		IdentityNegativeCacheTTL: config.IdentityNegativeCacheTTL,
+		IdentityCacheMaxEntries:  config.IdentityCacheMaxEntries,
+		PermissionsFile:          config.PermissionsFile,
+		RequesterRateLimit:       service.RateLimit{PerMinute: config.RateLimitRequesterPerMinute, Burst: config.RateLimitRequesterBurst},
+		ClassRateLimit:           service.RateLimit{PerMinute: config.RateLimitClassPerMinute, Burst: config.RateLimitClassBurst},
+		SchedulerMaxAge:          config.ReadySchedulerMaxAge,
+		OutboxMaxAge:             config.ReadyOutboxMaxAge,
+		OutboxRetention:          config.OutboxRetention,
+		LeaderID:                 config.LeaderID,
+		LeaderLeaseTTL:           config.LeaderLeaseTTL,
+	}, authenticator)
+	if err != nil {
+		fatal("failed to initialize application", err)
+	}
 	shutdownCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	startAnnouncementLoop(shutdownCtx, application, logger)
+	go application.RunLeaderElection(shutdownCtx)
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		application.RunScheduler(shutdownCtx)
+	}()
 
 	server := &http.Server{
 		Addr:              config.HTTPAddr,
@@ -78,44 +129,91 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		if err := server.Shutdown(ctx); err != nil {
-			logger.Printf("http shutdown error: %v", err)
+			slog.Error("http shutdown error", "error", err)
 		}
 	}()
 
-	logger.Printf("service-timetable listening on %s", config.HTTPAddr)
+	slog.Info("service-timetable listening", "http_addr", config.HTTPAddr)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		logger.Fatalf("http server error: %v", err)
+		fatal("http server error", err)
 	}
+	<-schedulerDone
 }
 
-func startAnnouncementLoop(ctx context.Context, application *app.App, logger *log.Logger) {
-	ticker := time.NewTicker(1 * time.Minute)
-	go func() {
-		defer ticker.Stop()
-		if err := application.EmitDailyAnnouncementIfDue(context.Background(), time.Now()); err != nil {
-			logger.Printf("announcement tick error: %v", err)
-		}
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case now := <-ticker.C:
-				if err := application.EmitDailyAnnouncementIfDue(context.Background(), now); err != nil {
-					logger.Printf("announcement tick error: %v", err)
-				}
-			}
-		}
-	}()
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
+}
+
+func newAuthenticator(cfg config) (*auth.Authenticator, error) {
+	if cfg.AuthMode == auth.ModeTrustedGateway {
+		return auth.NewTrustedGatewayAuthenticator(), nil
+	}
+
+	var keys *auth.JWKS
+	if cfg.JWKSFile != "" {
+		keys = auth.NewFileJWKS(cfg.JWKSFile, cfg.JWKSRefreshInterval)
+	} else {
+		keys = auth.NewURLJWKS(cfg.JWKSURL, &http.Client{Timeout: 5 * time.Second}, cfg.JWKSRefreshInterval)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := keys.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	verifier, err := auth.NewVerifier(keys, auth.VerifierConfig{
+		Issuer:     cfg.JWTIssuer,
+		Audience:   cfg.JWTAudience,
+		UserClaim:  cfg.JWTUserClaim,
+		RolesClaim: cfg.JWTRolesClaim,
+		ClockSkew:  cfg.JWTClockSkew,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewJWTAuthenticator(verifier), nil
 }
 
 type config struct {
-	DatabaseURL       string
-	HTTPAddr          string
-	LogLevel          string
-	IdentityBaseURL   string
-	DBMaxOpenConns    int
-	DBMaxIdleConns    int
-	DBConnMaxLifetime time.Duration
+	DatabaseURL                 string
+	HTTPAddr                    string
+	LogLevel                    string
+	TracingExporter             string
+	IdentityProvider            string
+	IdentityStaticFile          string
+	IdentityBaseURL             string
+	IdentityTimeout             time.Duration
+	IdentityMaxRetries          int
+	IdentityRetryBaseDelay      time.Duration
+	IdentityRetryMaxDelay       time.Duration
+	IdentityBreakerFailures     int
+	IdentityBreakerCooldown     time.Duration
+	IdentityCacheTTL            time.Duration
+	IdentityNegativeCacheTTL    time.Duration
+	IdentityCacheMaxEntries     int
+	PermissionsFile             string
+	RateLimitRequesterPerMinute int
+	RateLimitRequesterBurst     int
+	RateLimitClassPerMinute     int
+	RateLimitClassBurst         int
+	ReadySchedulerMaxAge        time.Duration
+	ReadyOutboxMaxAge           time.Duration
+	OutboxRetention             time.Duration
+	LeaderID                    string
+	LeaderLeaseTTL              time.Duration
+	DBMaxOpenConns              int
+	DBMaxIdleConns              int
+	DBConnMaxLifetime           time.Duration
+	AuthMode                    string
+	JWKSFile                    string
+	JWKSURL                     string
+	JWKSRefreshInterval         time.Duration
+	JWTIssuer                   string
+	JWTAudience                 string
+	JWTUserClaim                string
+	JWTRolesClaim               string
+	JWTClockSkew                time.Duration
 }
 
 func loadConfig() (config, error) {
@@ -127,9 +225,70 @@ func loadConfig() (config, error) {
 	}
 	cfg.HTTPAddr = getEnv("HTTP_ADDR", ":8080")
 	cfg.LogLevel = getEnv("LOG_LEVEL", "info")
-	if cfg.IdentityBaseURL, err = getRequiredEnv("IDENTITY_BASE_URL"); err != nil {
+	cfg.TracingExporter = getEnv("TRACING_EXPORTER", tracing.ExporterNone)
+	cfg.IdentityProvider = getEnv("IDENTITY_PROVIDER", app.IdentityProviderHTTP)
+	cfg.IdentityStaticFile = strings.TrimSpace(os.Getenv("IDENTITY_STATIC_FILE"))
+	if cfg.IdentityProvider == app.IdentityProviderHTTP {
+		if cfg.IdentityBaseURL, err = getRequiredEnv("IDENTITY_BASE_URL"); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.IdentityTimeout, err = getEnvDuration("IDENTITY_TIMEOUT", 5*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.IdentityMaxRetries, err = getEnvInt("IDENTITY_MAX_RETRIES", 2); err != nil {
+		return cfg, err
+	}
+	if cfg.IdentityRetryBaseDelay, err = getEnvDuration("IDENTITY_RETRY_BASE_DELAY", 100*time.Millisecond); err != nil {
 		return cfg, err
 	}
+	if cfg.IdentityRetryMaxDelay, err = getEnvDuration("IDENTITY_RETRY_MAX_DELAY", 2*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.IdentityBreakerFailures, err = getEnvInt("IDENTITY_BREAKER_FAILURES", 5); err != nil {
+		return cfg, err
+	}
+	if cfg.IdentityBreakerCooldown, err = getEnvDuration("IDENTITY_BREAKER_COOLDOWN", 30*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.IdentityCacheTTL, err = getEnvDuration("IDENTITY_CACHE_TTL", time.Minute); err != nil {
+		return cfg, err
+	}
+	if cfg.IdentityNegativeCacheTTL, err = getEnvDuration("IDENTITY_NEGATIVE_CACHE_TTL", 30*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.IdentityCacheMaxEntries, err = getEnvInt("IDENTITY_CACHE_MAX_ENTRIES", 10000); err != nil {
+		return cfg, err
+	}
+	cfg.PermissionsFile = strings.TrimSpace(os.Getenv("PERMISSIONS_FILE"))
+	if cfg.RateLimitRequesterPerMinute, err = getEnvInt("RATE_LIMIT_REQUESTER_PER_MINUTE", 60); err != nil {
+		return cfg, err
+	}
+	if cfg.RateLimitRequesterBurst, err = getEnvInt("RATE_LIMIT_REQUESTER_BURST", 20); err != nil {
+		return cfg, err
+	}
+	if cfg.RateLimitClassPerMinute, err = getEnvInt("RATE_LIMIT_CLASS_PER_MINUTE", 120); err != nil {
+		return cfg, err
+	}
+	if cfg.RateLimitClassBurst, err = getEnvInt("RATE_LIMIT_CLASS_BURST", 40); err != nil {
+		return cfg, err
+	}
+	if cfg.ReadySchedulerMaxAge, err = getEnvDuration("READY_SCHEDULER_MAX_AGE", 2*time.Minute); err != nil {
+		return cfg, err
+	}
+	if cfg.ReadyOutboxMaxAge, err = getEnvDuration("READY_OUTBOX_MAX_AGE", 5*time.Minute); err != nil {
+		return cfg, err
+	}
+	if cfg.OutboxRetention, err = getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour); err != nil {
+		return cfg, err
+	}
+	cfg.LeaderID = strings.TrimSpace(os.Getenv("LEADER_ID"))
+	if cfg.LeaderLeaseTTL, err = getEnvDuration("LEADER_LEASE_TTL", 15*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.LeaderLeaseTTL < 3*time.Second {
+		return cfg, &configError{message: "LEADER_LEASE_TTL must be at least 3s"}
+	}
 	if cfg.DBMaxOpenConns, err = getEnvInt("DB_MAX_OPEN_CONNS", 10); err != nil {
 		return cfg, err
 	}
@@ -140,6 +299,33 @@ func loadConfig() (config, error) {
 		return cfg, err
 	}
 
+	cfg.AuthMode = getEnv("AUTH_MODE", auth.ModeJWT)
+	switch cfg.AuthMode {
+	case auth.ModeJWT:
+		cfg.JWKSFile = strings.TrimSpace(os.Getenv("JWT_JWKS_FILE"))
+		cfg.JWKSURL = strings.TrimSpace(os.Getenv("JWT_JWKS_URL"))
+		if (cfg.JWKSFile == "") == (cfg.JWKSURL == "") {
+			return cfg, &configError{message: "exactly one of JWT_JWKS_FILE or JWT_JWKS_URL is required when AUTH_MODE=jwt"}
+		}
+		if cfg.JWTIssuer, err = getRequiredEnv("JWT_ISSUER"); err != nil {
+			return cfg, err
+		}
+		if cfg.JWTAudience, err = getRequiredEnv("JWT_AUDIENCE"); err != nil {
+			return cfg, err
+		}
+		cfg.JWTUserClaim = getEnv("JWT_USER_CLAIM", "sub")
+		cfg.JWTRolesClaim = getEnv("JWT_ROLES_CLAIM", "roles")
+		if cfg.JWTClockSkew, err = getEnvDuration("JWT_CLOCK_SKEW", 30*time.Second); err != nil {
+			return cfg, err
+		}
+		if cfg.JWKSRefreshInterval, err = getEnvDuration("JWT_JWKS_REFRESH_INTERVAL", 10*time.Minute); err != nil {
+			return cfg, err
+		}
+	case auth.ModeTrustedGateway:
+	default:
+		return cfg, &configError{message: "invalid AUTH_MODE: " + cfg.AuthMode}
+	}
+
 	return cfg, nil
 }
 
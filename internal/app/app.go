@@ -6,32 +6,105 @@ import (
 	"net/http"
 	"time"
 
+	"service-timetable/internal/auth"
+	"service-timetable/internal/health"
 	transport "service-timetable/internal/http"
 	"service-timetable/internal/http/handlers"
+	"service-timetable/internal/leader"
+	"service-timetable/internal/metrics"
 	"service-timetable/internal/repository"
+	"service-timetable/internal/scheduler"
 	"service-timetable/internal/service"
 )
 
+type Config struct {
+	IdentityProvider         string
+	IdentityStaticFile       string
+	IdentityBaseURL          string
+	IdentityTimeout          time.Duration
+	IdentityMaxRetries       int
+	IdentityRetryBaseDelay   time.Duration
+	IdentityRetryMaxDelay    time.Duration
+	IdentityBreakerFailures  int
+	IdentityBreakerCooldown  time.Duration
+	IdentityCacheTTL         time.Duration
+	IdentityNegativeCacheTTL time.Duration
+	IdentityCacheMaxEntries  int
+	PermissionsFile          string
+	RequesterRateLimit       service.RateLimit
+	ClassRateLimit           service.RateLimit
+	SchedulerMaxAge          time.Duration
+	OutboxMaxAge             time.Duration
+	OutboxRetention          time.Duration
+	LeaderID                 string
+	LeaderLeaseTTL           time.Duration
+}
+
+const schedulerLease = "scheduler"
+
 type App struct {
 	handler          http.Handler
 	timetableService *service.TimetableService
+	elector          *leader.Elector
+	scheduler        *scheduler.Scheduler
+	checks           []health.Check
 }
 
-func New(db *sql.DB, identityBaseURL string) *App {
+func New(db *sql.DB, config Config, authenticator *auth.Authenticator) (*App, error) {
 	txManager := repository.NewPostgresTxManager(db)
-	identityClient := service.NewIdentityHTTPClient(identityBaseURL, service.DefaultIdentityHTTPClient())
-	timetableService := service.NewTimetableService(txManager, identityClient)
+	metrics.RegisterOutboxBacklog(repository.NewOutboxPostgresRepository(db).Backlog)
+	identityClient, err := newIdentityClient(config, authenticator)
+	if err != nil {
+		return nil, err
+	}
+	permissions := service.DefaultPermissions()
+	if config.PermissionsFile != "" {
+		if permissions, err = service.LoadPermissions(config.PermissionsFile); err != nil {
+			return nil, err
+		}
+	}
+	timetableService := service.NewTimetableService(txManager, identityClient, permissions)
+	authenticator = authenticator.WithAPIKeys(timetableService)
 
-	adminHandler := handlers.NewAdminHandler(timetableService)
-	router := transport.NewRouter(adminHandler)
+	leaderID := config.LeaderID
+	if leaderID == "" {
+		leaderID = leader.DefaultIdentity()
+	}
+	elector := leader.NewElector(repository.NewLeaderLeasePostgresRepository(db), schedulerLease, leaderID, config.LeaderLeaseTTL)
+	heartbeat := &health.Heartbeat{}
+	jobs := scheduler.New(elector.IsLeader, heartbeat)
+	if err := registerJobs(jobs, timetableService, config); err != nil {
+		return nil, err
+	}
+	application := &App{
+		timetableService: timetableService,
+		elector:          elector,
+		scheduler:        jobs,
+		checks:           readinessChecks(db, config, heartbeat, elector),
+	}
 
-	return &App{handler: router.Handler(), timetableService: timetableService}
+	adminHandler := handlers.NewAdminHandler(timetableService, jobs)
+	healthHandler := handlers.NewHealthHandler(application)
+	limiter := service.NewRateLimiter(config.RequesterRateLimit, config.ClassRateLimit)
+	router, err := transport.NewRouter(adminHandler, healthHandler, authenticator, limiter)
+	if err != nil {
+		return nil, err
+	}
+	application.handler = router.Handler()
+	return application, nil
 }
 
 func (a *App) Handler() http.Handler {
 	return a.handler
 }
 
-func (a *App) EmitDailyAnnouncementIfDue(ctx context.Context, now time.Time) error {
-	return a.timetableService.EmitDailyAnnouncementIfDue(ctx, now)
+// RunLeaderElection campaigns for the scheduler lease until ctx is done.
+func (a *App) RunLeaderElection(ctx context.Context) {
+	a.elector.Run(ctx)
+}
+
+// RunScheduler runs scheduled jobs until ctx is done and returns once the
+// jobs that were running have finished.
+func (a *App) RunScheduler(ctx context.Context) {
+	a.scheduler.Run(ctx)
 }
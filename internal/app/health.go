@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"service-timetable/internal/health"
+	"service-timetable/internal/leader"
+	"service-timetable/internal/repository"
+	servicemigrations "service-timetable/migrations"
+)
+
+const readinessTimeout = 2 * time.Second
+
+func (a *App) Ready(ctx context.Context) health.Report {
+	return health.Run(ctx, a.checks, readinessTimeout)
+}
+
+func readinessChecks(db *sql.DB, config Config, scheduler *health.Heartbeat, elector *leader.Elector) []health.Check {
+	outbox := repository.NewOutboxPostgresRepository(db)
+	return []health.Check{
+		{
+			Name:     "database",
+			Critical: true,
+			Run: func(ctx context.Context) health.Result {
+				if err := db.PingContext(ctx); err != nil {
+					return health.Fail(err, nil)
+				}
+				return health.OK(nil)
+			},
+		},
+		{
+			Name:     "migrations",
+			Critical: true,
+			Run: func(ctx context.Context) health.Result {
+				current, pending, err := servicemigrations.Status(ctx, db)
+				if err != nil {
+					return health.Fail(err, nil)
+				}
+				details := map[string]any{"version": current, "expected": servicemigrations.Expected()}
+				if len(pending) > 0 {
+					details["pending"] = pending
+					return health.Fail(fmt.Errorf("%d migrations not applied", len(pending)), details)
+				}
+				return health.OK(details)
+			},
+		},
+		{
+			// Failed job runs are reported by GET /admin/jobs and the job
+			// metrics; this only checks that the scheduler loop is alive.
+			Name:     "scheduler",
+			Critical: true,
+			Run: func(ctx context.Context) health.Result {
+				last, _ := scheduler.Last()
+				if last.IsZero() {
+					return health.Fail(errors.New("scheduler not started"), nil)
+				}
+				details := map[string]any{"last_tick": last.UTC().Format(time.RFC3339)}
+				if age := time.Since(last); age > config.SchedulerMaxAge {
+					return health.Fail(fmt.Errorf("last tick %s ago", age.Round(time.Second)), details)
+				}
+				return health.OK(details)
+			},
+		},
+		{
+			// Followers are healthy; a missing leader means scheduled jobs
+			// are not running, which any replica may fix by taking over.
+			Name: "leader",
+			Run: func(ctx context.Context) health.Result {
+				status := elector.Status()
+				details := map[string]any{"self": status.Self, "is_leader": status.IsLeader}
+				if status.Leader == "" {
+					return health.Fail(errors.New("no replica holds the scheduler lease"), details)
+				}
+				details["leader"] = status.Leader
+				details["lease_expires_at"] = status.ExpiresAt.UTC().Format(time.RFC3339)
+				return health.OK(details)
+			},
+		},
+		{
+			// The relay publishing the outbox runs elsewhere; a stuck relay is
+			// reported but does not take this service out of rotation.
+			Name: "outbox_relay",
+			Run: func(ctx context.Context) health.Result {
+				count, oldest, err := outbox.Backlog(ctx)
+				if err != nil {
+					return health.Fail(err, nil)
+				}
+				details := map[string]any{"backlog": count}
+				if oldest.IsZero() {
+					return health.OK(details)
+				}
+				age := time.Since(oldest)
+				details["oldest_age_seconds"] = int(age.Seconds())
+				if age > config.OutboxMaxAge {
+					return health.Fail(fmt.Errorf("oldest unpublished event is %s old", age.Round(time.Second)), details)
+				}
+				return health.OK(details)
+			},
+		},
+	}
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"service-timetable/internal/auth"
+	"service-timetable/internal/metrics"
+	"service-timetable/internal/service"
+)
+
+const (
+	IdentityProviderHTTP      = "http"
+	IdentityProviderStatic    = "static"
+	IdentityProviderJWTClaims = "jwt-claims"
+)
+
+// newIdentityClient builds the configured identity provider after checking
+// that its settings are usable. Only the HTTP provider is cached and exports
+// cache and breaker metrics; the others answer from memory.
+func newIdentityClient(config Config, authenticator *auth.Authenticator) (service.IdentityClient, error) {
+	switch config.IdentityProvider {
+	case IdentityProviderHTTP, "":
+		baseURL, err := url.Parse(config.IdentityBaseURL)
+		if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+			return nil, fmt.Errorf("identity provider %s: base URL %q must be an absolute http(s) URL", IdentityProviderHTTP, config.IdentityBaseURL)
+		}
+		httpClient := service.NewIdentityHTTPClient(service.IdentityHTTPClientConfig{
+			BaseURL:        config.IdentityBaseURL,
+			HTTPClient:     &http.Client{Timeout: config.IdentityTimeout},
+			MaxRetries:     config.IdentityMaxRetries,
+			RetryBaseDelay: config.IdentityRetryBaseDelay,
+			RetryMaxDelay:  config.IdentityRetryMaxDelay,
+			Breaker:        service.NewCircuitBreaker(config.IdentityBreakerFailures, config.IdentityBreakerCooldown),
+		})
+		cached := service.NewCachedIdentityClient(
+			httpClient,
+			config.IdentityCacheTTL,
+			config.IdentityNegativeCacheTTL,
+			config.IdentityCacheMaxEntries,
+		)
+		metrics.RegisterIdentityCache(func() metrics.IdentityCacheStats {
+			return metrics.IdentityCacheStats(cached.Stats())
+		})
+		return cached, nil
+	case IdentityProviderStatic:
+		if config.IdentityStaticFile == "" {
+			return nil, fmt.Errorf("identity provider %s: a users file is required", IdentityProviderStatic)
+		}
+		static, err := service.LoadStaticIdentityClient(config.IdentityStaticFile)
+		if err != nil {
+			return nil, fmt.Errorf("identity provider %s: %w", IdentityProviderStatic, err)
+		}
+		return static, nil
+	case IdentityProviderJWTClaims:
+		if authenticator.Mode() != auth.ModeJWT {
+			return nil, fmt.Errorf("identity provider %s requires %s authentication", IdentityProviderJWTClaims, auth.ModeJWT)
+		}
+		return service.NewClaimsIdentityClient(), nil
+	default:
+		return nil, fmt.Errorf("unknown identity provider %q", config.IdentityProvider)
+	}
+}
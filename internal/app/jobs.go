@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"service-timetable/internal/scheduler"
+	"service-timetable/internal/service"
+)
+
+func registerJobs(jobs *scheduler.Scheduler, timetableService *service.TimetableService, config Config) error {
+	for _, job := range []scheduler.Job{
+		{
+			Name:     "announcements",
+			Schedule: "* * * * *",
+			Timeout:  50 * time.Second,
+			Run: func(ctx context.Context, scheduled time.Time) error {
+				return timetableService.EmitDueAnnouncements(ctx, scheduled)
+			},
+		},
+		{
+			Name:     "slot-reminders",
+			Schedule: "* * * * *",
+			Timeout:  50 * time.Second,
+			Run: func(ctx context.Context, scheduled time.Time) error {
+				return timetableService.EmitDueSlotReminders(ctx, scheduled)
+			},
+		},
+		{
+			Name:     "slot-lifecycle",
+			Schedule: "* * * * *",
+			Timeout:  50 * time.Second,
+			Run: func(ctx context.Context, scheduled time.Time) error {
+				return timetableService.EmitDueSlotLifecycleEvents(ctx, scheduled)
+			},
+		},
+		{
+			Name:     "outbox-cleanup",
+			Schedule: "17 * * * *",
+			Timeout:  5 * time.Minute,
+			Run: func(ctx context.Context, scheduled time.Time) error {
+				deleted, err := timetableService.PurgePublishedOutbox(ctx, scheduled.Add(-config.OutboxRetention))
+				if err != nil {
+					return err
+				}
+				slog.InfoContext(ctx, "published outbox events purged", "deleted", deleted)
+				return nil
+			},
+		},
+		{
+			Name:     "idempotency-cleanup",
+			Schedule: "*/15 * * * *",
+			Timeout:  time.Minute,
+			Run: func(ctx context.Context, scheduled time.Time) error {
+				deleted, err := timetableService.PurgeExpiredIdempotencyKeys(ctx, scheduled)
+				if err != nil {
+					return err
+				}
+				slog.InfoContext(ctx, "expired idempotency keys purged", "deleted", deleted)
+				return nil
+			},
+		},
+		{
+			Name:     "slot-record-cleanup",
+			Schedule: "40 3 * * *",
+			Timeout:  time.Minute,
+			Run: func(ctx context.Context, scheduled time.Time) error {
+				deleted, err := timetableService.PurgeSlotRecords(ctx, scheduled)
+				if err != nil {
+					return err
+				}
+				slog.InfoContext(ctx, "slot reminder and lifecycle records purged", "deleted", deleted)
+				return nil
+			},
+		},
+	} {
+		if err := jobs.Register(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// minForcedRefresh bounds how often an unknown key ID can trigger a reload,
+// so tokens with made up key IDs cannot hammer the JWKS endpoint.
+const minForcedRefresh = time.Minute
+
+// After a failed refresh the next attempt waits minRefreshBackoff, doubling
+// with every further failure up to maxRefreshBackoff.
+const (
+	minRefreshBackoff = 5 * time.Second
+	maxRefreshBackoff = 5 * time.Minute
+)
+
+// errUnsupportedKey marks keys of a type or curve the verifier cannot use.
+// They are skipped so that an issuer publishing new kinds of keys does not
+// break verification with the keys it already uses.
+var errUnsupportedKey = errors.New("unsupported key")
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type publicKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// JWKS holds the verification keys of a token issuer, loaded from a local file
+// or a URL and reloaded periodically. Keys are fetched without holding mu, and
+// concurrent callers share a single fetch.
+type JWKS struct {
+	load            func(ctx context.Context) ([]byte, error)
+	refreshInterval time.Duration
+	clock           func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]publicKey
+	loadedAt    time.Time
+	attemptedAt time.Time
+	failures    int
+	lastErr     error
+	inflight    *refreshCall
+}
+
+// refreshCall is a fetch in progress; done is closed once err is set.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+func NewFileJWKS(path string, refreshInterval time.Duration) *JWKS {
+	return &JWKS{
+		load: func(context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+		refreshInterval: refreshInterval,
+		clock:           time.Now,
+	}
+}
+
+func NewURLJWKS(url string, httpClient *http.Client, refreshInterval time.Duration) *JWKS {
+	return &JWKS{
+		load: func(ctx context.Context) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("jwks endpoint unexpected status: %d", resp.StatusCode)
+			}
+			return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		},
+		refreshInterval: refreshInterval,
+		clock:           time.Now,
+	}
+}
+
+// Refresh reloads the key set. It is called on startup so that a missing or
+// malformed key set stops the service instead of rejecting every request.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.mu.Lock()
+	call, leader := j.joinRefreshLocked()
+	j.mu.Unlock()
+	return j.awaitRefresh(ctx, call, leader)
+}
+
+func (j *JWKS) key(ctx context.Context, kid string) (publicKey, error) {
+	j.mu.Lock()
+	now := j.clock()
+	var refreshErr error
+	if (j.keys == nil || now.Sub(j.loadedAt) >= j.refreshInterval) && now.Sub(j.attemptedAt) >= j.backoffLocked() {
+		call, leader := j.joinRefreshLocked()
+		j.mu.Unlock()
+		// A failed periodic refresh keeps the keys loaded before.
+		refreshErr = j.awaitRefresh(ctx, call, leader)
+		j.mu.Lock()
+	}
+
+	key, ok := j.keys[kid]
+	if ok {
+		j.mu.Unlock()
+		return key, nil
+	}
+	if j.keys == nil {
+		lastErr := j.lastErr
+		j.mu.Unlock()
+		if refreshErr != nil {
+			return publicKey{}, refreshErr
+		}
+		return publicKey{}, fmt.Errorf("jwks unavailable: %w", lastErr)
+	}
+	since := j.clock().Sub(j.attemptedAt)
+	if since < minForcedRefresh || since < j.backoffLocked() {
+		j.mu.Unlock()
+		return publicKey{}, fmt.Errorf("unknown key id %q", kid)
+	}
+	call, leader := j.joinRefreshLocked()
+	j.mu.Unlock()
+
+	if err := j.awaitRefresh(ctx, call, leader); err != nil {
+		return publicKey{}, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	return publicKey{}, fmt.Errorf("unknown key id %q", kid)
+}
+
+// backoffLocked returns how long to wait after the last attempt before
+// fetching again; it is zero unless that attempt failed.
+func (j *JWKS) backoffLocked() time.Duration {
+	if j.failures == 0 {
+		return 0
+	}
+	backoff := minRefreshBackoff << min(j.failures-1, 16)
+	return min(backoff, maxRefreshBackoff)
+}
+
+// joinRefreshLocked returns the fetch in flight, or starts a new one that the
+// caller, being its leader, has to run with awaitRefresh.
+func (j *JWKS) joinRefreshLocked() (call *refreshCall, leader bool) {
+	if j.inflight != nil {
+		return j.inflight, false
+	}
+	j.inflight = &refreshCall{done: make(chan struct{})}
+	j.attemptedAt = j.clock()
+	return j.inflight, true
+}
+
+// awaitRefresh runs the fetch of call when leader is set and otherwise waits
+// for it, returning its error. mu must not be held.
+func (j *JWKS) awaitRefresh(ctx context.Context, call *refreshCall, leader bool) error {
+	if !leader {
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	keys, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	if err != nil {
+		j.failures++
+		j.lastErr = err
+	} else {
+		j.keys = keys
+		j.loadedAt = j.clock()
+		j.failures = 0
+		j.lastErr = nil
+	}
+	j.inflight = nil
+	j.mu.Unlock()
+
+	call.err = err
+	close(call.done)
+	return err
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]publicKey, error) {
+	data, err := j.load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load jwks: %w", err)
+	}
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJSONWebKey(jwk)
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode jwks key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = publicKey{key: key, alg: jwk.Alg}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no signing keys")
+	}
+	return keys, nil
+}
+
+func parseJSONWebKey(jwk jsonWebKey) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("rsa exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: curve %q", errUnsupportedKey, jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		point := append([]byte{4}, x...)
+		return ecdsa.ParseUncompressedPublicKey(curve, append(point, y...))
+	default:
+		return nil, fmt.Errorf("%w: key type %q", errUnsupportedKey, jwk.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
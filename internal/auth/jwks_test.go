@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]any {
+	return map[string]any{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func generateRSAKey(t *testing.T) *rsa.PublicKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return &key.PublicKey
+}
+
+// jwksServer serves the key set last stored with set, or a 500 while
+// failing is true, and counts the requests it answers.
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	body     []byte
+	failing  bool
+	requests atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...map[string]any) *jwksServer {
+	t.Helper()
+	s := &jwksServer{}
+	s.set(t, keys...)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(s.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(t *testing.T, keys ...map[string]any) {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatalf("encode jwks: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+}
+
+func (s *jwksServer) fail(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = failing
+}
+
+// newTestURLJWKS returns a key set loaded from server and a pointer to the
+// time its clock reports.
+func newTestURLJWKS(t *testing.T, server *jwksServer) (*JWKS, *time.Time) {
+	t.Helper()
+	now := testNow
+	jwks := NewURLJWKS(server.URL, server.Client(), time.Hour)
+	jwks.clock = func() time.Time { return now }
+	if err := jwks.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	return jwks, &now
+}
+
+func requireKey(t *testing.T, jwks *JWKS, kid string) {
+	t.Helper()
+	if _, err := jwks.key(context.Background(), kid); err != nil {
+		t.Fatalf("key(%s): %v", kid, err)
+	}
+}
+
+func requireUnknownKey(t *testing.T, jwks *JWKS, kid string) {
+	t.Helper()
+	_, err := jwks.key(context.Background(), kid)
+	if err == nil || !strings.Contains(err.Error(), "unknown key id") {
+		t.Fatalf("key(%s) error = %v, want an unknown key id", kid, err)
+	}
+}
+
+func requireRequests(t *testing.T, server *jwksServer, want int32) {
+	t.Helper()
+	if got := server.requests.Load(); got != want {
+		t.Fatalf("jwks requests = %d, want %d", got, want)
+	}
+}
+
+func TestURLJWKSRotation(t *testing.T) {
+	oldKey := rsaJWK("old", generateRSAKey(t))
+	newKey := rsaJWK("new", generateRSAKey(t))
+	server := newJWKSServer(t, oldKey)
+	jwks, now := newTestURLJWKS(t, server)
+
+	requireKey(t, jwks, "old")
+	server.set(t, oldKey, newKey)
+	*now = now.Add(59 * time.Minute)
+	requireKey(t, jwks, "old")
+	requireRequests(t, server, 1)
+
+	*now = now.Add(time.Minute)
+	requireKey(t, jwks, "new")
+	requireRequests(t, server, 2)
+
+	server.set(t, newKey)
+	*now = now.Add(time.Hour)
+	requireKey(t, jwks, "new")
+	requireUnknownKey(t, jwks, "old")
+	requireRequests(t, server, 3)
+}
+
+func TestURLJWKSRefreshesOnUnknownKeyID(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK("old", generateRSAKey(t)))
+	jwks, now := newTestURLJWKS(t, server)
+	server.set(t, rsaJWK("old", generateRSAKey(t)), rsaJWK("new", generateRSAKey(t)))
+
+	*now = now.Add(minForcedRefresh - time.Second)
+	requireUnknownKey(t, jwks, "new")
+	requireRequests(t, server, 1)
+
+	*now = now.Add(time.Second)
+	requireKey(t, jwks, "new")
+	requireRequests(t, server, 2)
+
+	requireUnknownKey(t, jwks, "made-up")
+	requireRequests(t, server, 2)
+}
+
+func TestURLJWKSBacksOffAfterFailedRefresh(t *testing.T) {
+	server := newJWKSServer(t, rsaJWK("old", generateRSAKey(t)))
+	jwks, now := newTestURLJWKS(t, server)
+	server.fail(true)
+
+	*now = now.Add(time.Hour)
+	requireKey(t, jwks, "old")
+	requireRequests(t, server, 2)
+
+	*now = now.Add(minRefreshBackoff - time.Second)
+	requireKey(t, jwks, "old")
+	requireRequests(t, server, 2)
+
+	*now = now.Add(time.Second)
+	requireKey(t, jwks, "old")
+	requireRequests(t, server, 3)
+
+	*now = now.Add(minRefreshBackoff)
+	requireKey(t, jwks, "old")
+	requireRequests(t, server, 3)
+
+	server.fail(false)
+	*now = now.Add(minRefreshBackoff)
+	requireKey(t, jwks, "old")
+	requireRequests(t, server, 4)
+	*now = now.Add(time.Minute)
+	requireKey(t, jwks, "old")
+	requireRequests(t, server, 4)
+}
+
+func TestJWKSSkipsUnsupportedKeys(t *testing.T) {
+	server := newJWKSServer(t,
+		map[string]any{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		map[string]any{"kty": "EC", "kid": "k1", "crv": "secp256k1", "x": "AA", "y": "AA"},
+		rsaJWK("rsa", generateRSAKey(t)),
+	)
+	jwks, _ := newTestURLJWKS(t, server)
+
+	requireKey(t, jwks, "rsa")
+	requireUnknownKey(t, jwks, "ed")
+
+	server.set(t, map[string]any{"kty": "RSA", "kid": "broken", "n": "not base64!", "e": "AQAB"}, rsaJWK("rsa", generateRSAKey(t)))
+	if err := jwks.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh accepted a malformed RSA key")
+	}
+}
+
+func TestJWKSSharesConcurrentFetches(t *testing.T) {
+	body, err := json.Marshal(map[string]any{"keys": []any{rsaJWK("dev", generateRSAKey(t))}})
+	if err != nil {
+		t.Fatalf("encode jwks: %v", err)
+	}
+	var loads atomic.Int32
+	release := make(chan struct{})
+	jwks := &JWKS{
+		load: func(ctx context.Context) ([]byte, error) {
+			loads.Add(1)
+			<-release
+			return body, nil
+		},
+		refreshInterval: time.Hour,
+		clock:           func() time.Time { return testNow },
+	}
+
+	const callers = 8
+	errs := make(chan error, callers)
+	for range callers {
+		go func() {
+			_, err := jwks.key(context.Background(), "dev")
+			errs <- err
+		}()
+	}
+	for loads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// Once the fetch is in flight the lock must be free for other callers.
+	jwks.mu.Lock()
+	jwks.mu.Unlock()
+	close(release)
+
+	for range callers {
+		if err := <-errs; err != nil {
+			t.Errorf("key: %v", err)
+		}
+	}
+	if got := loads.Load(); got != 1 {
+		t.Errorf("loads = %d, want 1", got)
+	}
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type VerifierConfig struct {
+	Issuer     string
+	Audience   string
+	UserClaim  string
+	RolesClaim string
+	ClockSkew  time.Duration
+}
+
+// Verifier checks signed bearer tokens and maps their claims to a Principal.
+type Verifier struct {
+	keys   *JWKS
+	config VerifierConfig
+	clock  func() time.Time
+}
+
+func NewVerifier(keys *JWKS, config VerifierConfig) (*Verifier, error) {
+	if keys == nil {
+		return nil, errors.New("jwks is required")
+	}
+	if config.Issuer == "" {
+		return nil, errors.New("jwt issuer is required")
+	}
+	if config.Audience == "" {
+		return nil, errors.New("jwt audience is required")
+	}
+	if config.UserClaim == "" {
+		config.UserClaim = "sub"
+	}
+	if config.RolesClaim == "" {
+		config.RolesClaim = "roles"
+	}
+	return &Verifier{keys: keys, config: config, clock: time.Now}, nil
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type tokenRole struct {
+	Name       string     `json:"name"`
+	ClassID    *uuid.UUID `json:"class_id"`
+	CourseCode string     `json:"course_code"`
+}
+
+func (v *Verifier) Verify(ctx context.Context, token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, errors.New("token is not a compact JWS")
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("decode token header: %w", err)
+	}
+	hash, err := hashForAlg(header.Alg)
+	if err != nil {
+		return Principal{}, err
+	}
+	key, err := v.keys.key(ctx, header.Kid)
+	if err != nil {
+		return Principal{}, err
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return Principal{}, fmt.Errorf("token alg %s does not match key alg %s", header.Alg, key.alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("decode token signature: %w", err)
+	}
+	if err := verifySignature(key.key, header.Alg, hash, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return Principal{}, err
+	}
+
+	var claims map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("decode token claims: %w", err)
+	}
+	if err := v.checkRegisteredClaims(claims); err != nil {
+		return Principal{}, err
+	}
+	return v.principalFromClaims(claims)
+}
+
+func (v *Verifier) checkRegisteredClaims(claims map[string]json.RawMessage) error {
+	var issuer string
+	if err := unmarshalClaim(claims, "iss", &issuer); err != nil || issuer != v.config.Issuer {
+		return errors.New("token issuer mismatch")
+	}
+
+	var audiences []string
+	var audience string
+	if err := unmarshalClaim(claims, "aud", &audience); err == nil {
+		audiences = []string{audience}
+	} else if err := unmarshalClaim(claims, "aud", &audiences); err != nil {
+		return errors.New("token audience missing")
+	}
+	if !slices.Contains(audiences, v.config.Audience) {
+		return errors.New("token audience mismatch")
+	}
+
+	now := v.clock()
+	var expiresAt float64
+	if err := unmarshalClaim(claims, "exp", &expiresAt); err != nil {
+		return errors.New("token expiry missing")
+	}
+	if !now.Before(unixTime(expiresAt).Add(v.config.ClockSkew)) {
+		return errors.New("token expired")
+	}
+	var notBefore float64
+	if err := unmarshalClaim(claims, "nbf", &notBefore); err == nil {
+		if now.Add(v.config.ClockSkew).Before(unixTime(notBefore)) {
+			return errors.New("token not yet valid")
+		}
+	}
+	return nil
+}
+
+// principalFromClaims reads the user ID from the configured claim and roles
+// from either a list of role names or a list of {name, class_id, course_code}
+// objects.
+func (v *Verifier) principalFromClaims(claims map[string]json.RawMessage) (Principal, error) {
+	var subject string
+	if err := unmarshalClaim(claims, v.config.UserClaim, &subject); err != nil {
+		return Principal{}, fmt.Errorf("token claim %s missing", v.config.UserClaim)
+	}
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return Principal{}, fmt.Errorf("token claim %s is not a UUID", v.config.UserClaim)
+	}
+
+	principal := Principal{UserID: userID, Source: SourceJWT}
+	if _, ok := claims[v.config.RolesClaim]; !ok {
+		return principal, nil
+	}
+
+	var names []string
+	if err := unmarshalClaim(claims, v.config.RolesClaim, &names); err == nil {
+		for _, name := range names {
+			principal.Roles = append(principal.Roles, Role{Name: name})
+		}
+		return principal, nil
+	}
+	var roles []tokenRole
+	if err := unmarshalClaim(claims, v.config.RolesClaim, &roles); err != nil {
+		return Principal{}, fmt.Errorf("token claim %s is malformed", v.config.RolesClaim)
+	}
+	for _, role := range roles {
+		principal.Roles = append(principal.Roles, Role{Name: role.Name, ClassID: role.ClassID, CourseCode: role.CourseCode})
+	}
+	return principal, nil
+}
+
+func hashForAlg(alg string) (crypto.Hash, error) {
+	switch alg {
+	case "RS256", "ES256":
+		return crypto.SHA256, nil
+	case "RS384", "ES384":
+		return crypto.SHA384, nil
+	case "RS512", "ES512":
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported token alg %q", alg)
+	}
+}
+
+func verifySignature(key crypto.PublicKey, alg string, hash crypto.Hash, signed []byte, signature []byte) error {
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("token alg %s does not fit an RSA key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return errors.New("token signature invalid")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("token alg %s does not fit an EC key", alg)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("token signature invalid")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("token signature invalid")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key")
+	}
+}
+
+func decodeSegment(segment string, target any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func unmarshalClaim(claims map[string]json.RawMessage, name string, target any) error {
+	raw, ok := claims[name]
+	if !ok {
+		return fmt.Errorf("claim %s missing", name)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
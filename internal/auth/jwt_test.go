@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	testJWKSPath    = "../../dev/auth/jwks.json"
+	testKeyPath     = "../../dev/auth/jwt-signing-key.pem"
+	testIssuer      = "https://identity.example.org"
+	testAudience    = "service-timetable"
+	testSubject     = "5d1f3b1e-8f0c-4d8e-9c57-2f2b9b1a7e01"
+	testClassID     = "0f9d5c2a-3b4e-4c6d-8e7f-1a2b3c4d5e6f"
+	testKeyID       = "dev-1"
+	testTokenMaxAge = time.Hour
+)
+
+var testNow = time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+func loadSigningKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	data, err := os.ReadFile(testKeyPath)
+	if err != nil {
+		t.Fatalf("read signing key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("signing key is not PEM")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse signing key: %v", err)
+	}
+	return key.(*rsa.PrivateKey)
+}
+
+func newTestVerifier(t *testing.T) *Verifier {
+	t.Helper()
+	jwks := NewFileJWKS(testJWKSPath, time.Hour)
+	if err := jwks.Refresh(context.Background()); err != nil {
+		t.Fatalf("load jwks: %v", err)
+	}
+	verifier, err := NewVerifier(jwks, VerifierConfig{Issuer: testIssuer, Audience: testAudience})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	verifier.clock = func() time.Time { return testNow }
+	return verifier
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   testSubject,
+		"iat":   testNow.Unix(),
+		"exp":   testNow.Add(testTokenMaxAge).Unix(),
+		"roles": []map[string]any{{"name": "faculty", "class_id": testClassID, "course_code": "CS301"}},
+	}
+}
+
+func encodeSegment(t *testing.T, value any) string {
+	t.Helper()
+	raw, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("encode token segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// signToken signs header and claims with the dev key using RS256, whatever
+// alg the header names.
+func signToken(t *testing.T, key *rsa.PrivateKey, header map[string]any, claims map[string]any) string {
+	t.Helper()
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func rs256Header() map[string]any {
+	return map[string]any{"alg": "RS256", "kid": testKeyID, "typ": "JWT"}
+}
+
+func TestVerifyAcceptsValidToken(t *testing.T) {
+	verifier := newTestVerifier(t)
+	token := signToken(t, loadSigningKey(t), rs256Header(), validClaims())
+
+	principal, err := verifier.Verify(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if principal.UserID != uuid.MustParse(testSubject) {
+		t.Errorf("UserID = %s, want %s", principal.UserID, testSubject)
+	}
+	if principal.Source != SourceJWT {
+		t.Errorf("Source = %q, want %q", principal.Source, SourceJWT)
+	}
+	if len(principal.Roles) != 1 || principal.Roles[0].Name != "faculty" || principal.Roles[0].CourseCode != "CS301" ||
+		principal.Roles[0].ClassID == nil || principal.Roles[0].ClassID.String() != testClassID {
+		t.Errorf("Roles = %+v, want one faculty role for CS301 in %s", principal.Roles, testClassID)
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	key := loadSigningKey(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	withClaim := func(name string, value any) map[string]any {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+	withHeader := func(name string, value any) map[string]any {
+		header := rs256Header()
+		header[name] = value
+		return header
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{
+			name:  "bad signature",
+			token: signToken(t, otherKey, rs256Header(), validClaims()),
+			want:  "signature invalid",
+		},
+		{
+			name: "tampered claims",
+			token: func() string {
+				parts := strings.Split(signToken(t, key, rs256Header(), validClaims()), ".")
+				parts[1] = encodeSegment(t, withClaim("sub", uuid.NewString()))
+				return strings.Join(parts, ".")
+			}(),
+			want: "signature invalid",
+		},
+		{
+			name:  "wrong issuer",
+			token: signToken(t, key, rs256Header(), withClaim("iss", "https://evil.example.org")),
+			want:  "issuer mismatch",
+		},
+		{
+			name:  "wrong audience",
+			token: signToken(t, key, rs256Header(), withClaim("aud", []string{"service-other"})),
+			want:  "audience mismatch",
+		},
+		{
+			name:  "missing audience",
+			token: signToken(t, key, rs256Header(), withClaim("aud", nil)),
+			want:  "audience missing",
+		},
+		{
+			name:  "expired",
+			token: signToken(t, key, rs256Header(), withClaim("exp", testNow.Add(-time.Minute).Unix())),
+			want:  "expired",
+		},
+		{
+			name:  "missing expiry",
+			token: signToken(t, key, rs256Header(), withClaim("exp", nil)),
+			want:  "expiry missing",
+		},
+		{
+			name:  "not yet valid",
+			token: signToken(t, key, rs256Header(), withClaim("nbf", testNow.Add(time.Minute).Unix())),
+			want:  "not yet valid",
+		},
+		{
+			name:  "alg does not match key",
+			token: signToken(t, key, withHeader("alg", "RS384"), validClaims()),
+			want:  "does not match key alg",
+		},
+		{
+			name:  "unknown kid",
+			token: signToken(t, key, withHeader("kid", "dev-2"), validClaims()),
+			want:  "unknown key id",
+		},
+		{
+			name:  "alg none",
+			token: encodeSegment(t, withHeader("alg", "none")) + "." + encodeSegment(t, validClaims()) + ".",
+			want:  "unsupported token alg",
+		},
+		{
+			name:  "symmetric alg",
+			token: signToken(t, key, withHeader("alg", "HS256"), validClaims()),
+			want:  "unsupported token alg",
+		},
+		{
+			name:  "subject not a uuid",
+			token: signToken(t, key, rs256Header(), withClaim("sub", "alice")),
+			want:  "not a UUID",
+		},
+		{
+			name:  "not a jws",
+			token: "abc.def",
+			want:  "not a compact JWS",
+		},
+	}
+
+	verifier := newTestVerifier(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), tt.token)
+			if err == nil {
+				t.Fatal("Verify: expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Verify error = %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAllowsClockSkew(t *testing.T) {
+	verifier := newTestVerifier(t)
+	verifier.config.ClockSkew = 2 * time.Minute
+	claims := validClaims()
+	claims["exp"] = testNow.Add(-time.Minute).Unix()
+	claims["nbf"] = testNow.Add(time.Minute).Unix()
+
+	if _, err := verifier.Verify(context.Background(), signToken(t, loadSigningKey(t), rs256Header(), claims)); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ModeJWT            = "jwt"
+	ModeTrustedGateway = "trusted-gateway"
+)
+
+// APIKeyHeader carries service API keys. It is accepted in every mode once
+// an APIKeyResolver is configured.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyResolver looks up API keys. Unknown, expired and revoked keys are
+// reported wrapping ErrUnauthenticated; other errors are lookup failures.
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, key string) (Principal, error)
+}
+
+// Authenticator resolves the Principal of incoming requests. In JWT mode it
+// requires a verified bearer token; in trusted-gateway mode it accepts the
+// X-User-ID header as set by a gateway that has already authenticated the
+// caller, and must never be exposed directly.
+type Authenticator struct {
+	mode     string
+	verifier *Verifier
+	apiKeys  APIKeyResolver
+}
+
+func NewJWTAuthenticator(verifier *Verifier) *Authenticator {
+	return &Authenticator{mode: ModeJWT, verifier: verifier}
+}
+
+func NewTrustedGatewayAuthenticator() *Authenticator {
+	return &Authenticator{mode: ModeTrustedGateway}
+}
+
+func (a *Authenticator) Mode() string {
+	return a.mode
+}
+
+// WithAPIKeys returns a copy of a that also accepts API keys.
+func (a *Authenticator) WithAPIKeys(resolver APIKeyResolver) *Authenticator {
+	copied := *a
+	copied.apiKeys = resolver
+	return &copied
+}
+
+// Middleware authenticates every request before calling next. Failures are
+// passed to onError, wrapping ErrUnauthenticated unless a lookup failed.
+func (a *Authenticator) Middleware(next http.Handler, onError func(http.ResponseWriter, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.authenticate(r)
+		if err != nil {
+			onError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" && a.apiKeys != nil {
+		return a.apiKeys.ResolveAPIKey(r.Context(), key)
+	}
+
+	if a.mode == ModeTrustedGateway {
+		userID, err := uuid.Parse(r.Header.Get("X-User-ID"))
+		if err != nil {
+			return Principal{}, fmt.Errorf("%w: X-User-ID must be a UUID", ErrUnauthenticated)
+		}
+		return Principal{UserID: userID, Source: SourceTrustedGateway}, nil
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Principal{}, fmt.Errorf("%w: bearer token required", ErrUnauthenticated)
+	}
+	principal, err := a.verifier.Verify(r.Context(), strings.TrimSpace(token))
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	return principal, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	token := signToken(t, loadSigningKey(t), rs256Header(), validClaims())
+
+	tests := []struct {
+		name          string
+		authenticator *Authenticator
+		headers       map[string]string
+		wantUser      string
+		wantSource    string
+	}{
+		{
+			name:          "jwt mode with bearer token",
+			authenticator: NewJWTAuthenticator(newTestVerifier(t)),
+			headers:       map[string]string{"Authorization": "Bearer " + token},
+			wantUser:      testSubject,
+			wantSource:    SourceJWT,
+		},
+		{
+			name:          "jwt mode ignores the gateway header",
+			authenticator: NewJWTAuthenticator(newTestVerifier(t)),
+			headers:       map[string]string{"X-User-ID": testSubject},
+		},
+		{
+			name:          "jwt mode rejects a non bearer scheme",
+			authenticator: NewJWTAuthenticator(newTestVerifier(t)),
+			headers:       map[string]string{"Authorization": "Basic " + token},
+		},
+		{
+			name:          "jwt mode rejects an invalid token",
+			authenticator: NewJWTAuthenticator(newTestVerifier(t)),
+			headers:       map[string]string{"Authorization": "Bearer " + token + "x"},
+		},
+		{
+			name:          "trusted gateway mode with user header",
+			authenticator: NewTrustedGatewayAuthenticator(),
+			headers:       map[string]string{"X-User-ID": testSubject},
+			wantUser:      testSubject,
+			wantSource:    SourceTrustedGateway,
+		},
+		{
+			name:          "trusted gateway mode without user header",
+			authenticator: NewTrustedGatewayAuthenticator(),
+		},
+		{
+			name:          "trusted gateway mode with malformed user header",
+			authenticator: NewTrustedGatewayAuthenticator(),
+			headers:       map[string]string{"X-User-ID": "alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Principal
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal, ok := PrincipalFromContext(r.Context())
+				if !ok {
+					t.Error("no principal in context")
+				}
+				got = &principal
+			})
+			var gotErr error
+			onError := func(w http.ResponseWriter, err error) {
+				gotErr = err
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/timetable/today", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			tt.authenticator.Middleware(next, onError).ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.wantUser == "" {
+				if got != nil {
+					t.Fatalf("request passed as %s, want it rejected", got.UserID)
+				}
+				if !errors.Is(gotErr, ErrUnauthenticated) {
+					t.Errorf("error = %v, want ErrUnauthenticated", gotErr)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("request rejected: %v", gotErr)
+			}
+			if got.UserID.String() != tt.wantUser || got.Source != tt.wantSource {
+				t.Errorf("principal = %s from %s, want %s from %s", got.UserID, got.Source, tt.wantUser, tt.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+const (
+	SourceJWT            = "jwt"
+	SourceTrustedGateway = "trusted-gateway"
+	SourceAPIKey         = "api-key"
+)
+
+type Role struct {
+	Name       string
+	ClassID    *uuid.UUID
+	CourseCode string
+}
+
+// APIKeyGrant is what an API key may do. ClassIDs restrict scopes that act
+// on classes.
+type APIKeyGrant struct {
+	Name     string
+	Scopes   []string
+	ClassIDs []uuid.UUID
+}
+
+// Principal is the authenticated caller of a request. Roles are only known
+// when the credential carries them, as bearer tokens do. For API keys UserID
+// is the key ID and APIKey is set.
+type Principal struct {
+	UserID uuid.UUID
+	Roles  []Role
+	Source string
+	APIKey *APIKeyGrant
+}
+
+type principalContextKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
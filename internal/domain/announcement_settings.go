@@ -6,11 +6,32 @@ import (
 	"github.com/google/uuid"
 )
 
+// AnnouncementSettings configures the announcements of a class. Templates are
+// written in Locale; LocalizedUpdateTemplates adds update templates in other
+// locales, keyed by locale. Slot reminders are sent ReminderMinutes before
+// each slot starts, only for overridden slots when ReminderChangedOnly is set;
+// nil disables them.
 type AnnouncementSettings struct {
-	ClassID           uuid.UUID
-	MatrixRoomID      string
-	DailyAnnounceTime time.Time
-	DailyTemplate     string
-	UpdateTemplate    string
-	LastAnnouncedDate *time.Time
+	ClassID                  uuid.UUID
+	MatrixRoomID             string
+	Locale                   string
+	UpdateTemplate           string
+	LocalizedUpdateTemplates map[string]string
+	ReminderMinutes          *int
+	ReminderChangedOnly      bool
+	Schedules                []AnnouncementSchedule
+}
+
+// AnnouncementSchedule announces the timetable of the date DaysAhead days
+// after the day it runs on, at AnnounceTime local time. LastAnnouncedDate is
+// the latest date it announced. LocalizedTemplates holds the template in
+// locales other than the settings' locale.
+type AnnouncementSchedule struct {
+	ClassID            uuid.UUID
+	Name               string
+	DaysAhead          int
+	AnnounceTime       time.Time
+	Template           string
+	LocalizedTemplates map[string]string
+	LastAnnouncedDate  *time.Time
 }
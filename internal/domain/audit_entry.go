@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ActorUser   = "user"
+	ActorAPIKey = "api_key"
+)
+
+// AuditEntry records a change. ClassID is uuid.Nil for changes that are not
+// about a class, such as API key management.
+type AuditEntry struct {
+	ID         uuid.UUID
+	OccurredAt time.Time
+	ActorID    uuid.UUID
+	ActorKind  string
+	Action     string
+	ClassID    uuid.UUID
+	Date       *time.Time
+	Details    json.RawMessage
+}
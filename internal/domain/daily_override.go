@@ -15,5 +15,7 @@ type DailyOverride struct {
 	StartTime  *time.Time
 	EndTime    *time.Time
 	Venue      string
+	FacultyID  *uuid.UUID
 	Status     string
+	Version    int64
 }
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DayLock freezes a class's overrides on Date. SlotIndex 0 locks the whole
+// day; any other value locks only that slot.
+type DayLock struct {
+	ClassID   uuid.UUID
+	Date      time.Time
+	SlotIndex int
+	LockedBy  uuid.UUID
+	LockedAt  time.Time
+	Reason    string
+}
+
+func (l DayLock) Covers(slotIndex int) bool {
+	return l.SlotIndex == 0 || l.SlotIndex == slotIndex
+}
@@ -6,11 +6,15 @@ import (
 	"github.com/google/uuid"
 )
 
+// DefaultSlot is a slot of the default timetable of a weekday. SlotIndex is
+// assigned once and identifies the slot for overrides.
 type DefaultSlot struct {
 	ClassID    uuid.UUID
 	Weekday    int
+	SlotIndex  int
 	CourseCode string
 	StartTime  time.Time
 	EndTime    time.Time
 	Venue      string
+	FacultyID  *uuid.UUID
 }
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delegation lets DelegateID edit overrides of ClassID on the dates from
+// StartsOn to EndsOn inclusive, until it is revoked.
+type Delegation struct {
+	ID         uuid.UUID
+	ClassID    uuid.UUID
+	DelegateID uuid.UUID
+	GrantedBy  uuid.UUID
+	StartsOn   time.Time
+	EndsOn     time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+	RevokedBy  *uuid.UUID
+}
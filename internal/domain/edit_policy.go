@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EditPolicy limits when overrides may be written. With CutoffMinutes set,
+// a slot can no longer be overridden from that many minutes before its start;
+// nil disables the cutoff.
+type EditPolicy struct {
+	ClassID       uuid.UUID
+	CutoffMinutes *int
+	UpdatedAt     time.Time
+}
@@ -11,14 +11,33 @@ type TimetableSlotPayload struct {
 	StartTime  string `json:"start_time"`
 	EndTime    string `json:"end_time"`
 	Venue      string `json:"venue"`
+	FacultyID  string `json:"faculty_id,omitempty"`
 	Status     string `json:"status"`
 }
 
 type DailyTimetableAnnouncedPayload struct {
 	ClassID      string                 `json:"class_id"`
 	Date         string                 `json:"date"`
+	Schedule     string                 `json:"schedule"`
 	MatrixRoomID string                 `json:"matrix_room_id"`
 	Template     string                 `json:"template"`
+	Message      string                 `json:"message"`
+	Messages     map[string]string      `json:"messages"`
+	Slots        []TimetableSlotPayload `json:"slots"`
+}
+
+// TimetablePreviewAnnouncedPayload announces the timetable of Date ahead of
+// time, on AnnouncedOn.
+type TimetablePreviewAnnouncedPayload struct {
+	ClassID      string                 `json:"class_id"`
+	Date         string                 `json:"date"`
+	AnnouncedOn  string                 `json:"announced_on"`
+	DaysAhead    int                    `json:"days_ahead"`
+	Schedule     string                 `json:"schedule"`
+	MatrixRoomID string                 `json:"matrix_room_id"`
+	Template     string                 `json:"template"`
+	Message      string                 `json:"message"`
+	Messages     map[string]string      `json:"messages"`
 	Slots        []TimetableSlotPayload `json:"slots"`
 }
 
@@ -27,6 +46,36 @@ type TimetableUpdatedPayload struct {
 	Date           string                 `json:"date"`
 	MatrixRoomID   string                 `json:"matrix_room_id"`
 	UpdateTemplate string                 `json:"update_template"`
+	Message        string                 `json:"message"`
+	Messages       map[string]string      `json:"messages"`
 	Slots          []TimetableSlotPayload `json:"slots"`
 	UpdatedBy      string                 `json:"updated_by"`
 }
+
+// SlotReminderPayload announces that Slot starts in MinutesBefore minutes.
+// Changed is set when an override changed the slot for the date.
+type SlotReminderPayload struct {
+	ClassID       string               `json:"class_id"`
+	Date          string               `json:"date"`
+	MatrixRoomID  string               `json:"matrix_room_id"`
+	MinutesBefore int                  `json:"minutes_before"`
+	Changed       bool                 `json:"changed"`
+	Slot          TimetableSlotPayload `json:"slot"`
+}
+
+// SlotLifecyclePayload is the payload of SlotStarted and SlotEnded. SlotID
+// identifies the slot of the class on the date and is the same in both
+// events; At is the resolved start or end time.
+type SlotLifecyclePayload struct {
+	SlotID     string `json:"slot_id"`
+	ClassID    string `json:"class_id"`
+	Date       string `json:"date"`
+	SlotIndex  int    `json:"slot_index"`
+	CourseCode string `json:"course_code"`
+	Venue      string `json:"venue"`
+	FacultyID  string `json:"faculty_id,omitempty"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	Status     string `json:"status"`
+	At         string `json:"at"`
+}
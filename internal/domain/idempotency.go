@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRecord is a key claimed by a requester. A completed record holds
+// either the Response of a successful request or the Error of a failed one;
+// a record with neither belongs to a request still in flight.
+type IdempotencyRecord struct {
+	RequesterID uuid.UUID
+	Key         string
+	Fingerprint string
+	Response    json.RawMessage
+	Error       json.RawMessage
+	CreatedAt   time.Time
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// LeaderLease names the replica currently allowed to run a singleton task.
+// The holder keeps it by renewing before ExpiresAt; once expired, any other
+// replica may take it over.
+type LeaderLease struct {
+	Name       string
+	Holder     string
+	AcquiredAt time.Time
+	RenewedAt  time.Time
+	ExpiresAt  time.Time
+}
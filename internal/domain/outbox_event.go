@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OutboxEvent struct {
+	ID        uuid.UUID
+	EventType string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	Published bool
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ResolvedDay struct {
+	ClassID uuid.UUID
+	Date    time.Time
+	Version int64
+	Slots   []Slot
+	Locks   []DayLock
+}
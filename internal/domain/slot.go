@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Slot struct {
 	SlotIndex  int
@@ -8,5 +12,9 @@ type Slot struct {
 	StartTime  time.Time
 	EndTime    time.Time
 	Venue      string
+	FacultyID  *uuid.UUID
 	Status     string
+	Version    int64
+	// Overridden is set when an override for the date changed the slot.
+	Overridden bool
 }
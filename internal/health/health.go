@@ -0,0 +1,104 @@
+// Package health runs the readiness checks of the service.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Result is the outcome of one check. Details are reported as-is.
+type Result struct {
+	Status   string
+	Error    string
+	Details  map[string]any
+	Duration time.Duration
+}
+
+func OK(details map[string]any) Result {
+	return Result{Status: StatusOK, Details: details}
+}
+
+func Fail(err error, details map[string]any) Result {
+	return Result{Status: StatusFail, Error: err.Error(), Details: details}
+}
+
+// Check is a named readiness check. A failing check that is not critical is
+// reported as a warning and leaves the service ready.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) Result
+}
+
+type Report struct {
+	Status string
+	Checks map[string]Result
+}
+
+func (r Report) Ready() bool {
+	return r.Status != StatusFail
+}
+
+// Run runs the checks concurrently, each bounded by timeout.
+func Run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			result := check.Run(checkCtx)
+			result.Duration = time.Since(start)
+			if result.Status == StatusFail && !check.Critical {
+				result.Status = StatusWarn
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, check := range checks {
+		report.Checks[check.Name] = results[i]
+		switch {
+		case results[i].Status == StatusFail:
+			report.Status = StatusFail
+		case results[i].Status == StatusWarn && report.Status == StatusOK:
+			report.Status = StatusWarn
+		}
+	}
+	return report
+}
+
+// Heartbeat records the last run of a background loop.
+type Heartbeat struct {
+	mu      sync.Mutex
+	last    time.Time
+	lastErr error
+}
+
+func (h *Heartbeat) Beat(at time.Time, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = at
+	h.lastErr = err
+}
+
+// Last returns the time and error of the last run; the time is zero when
+// the loop has not run yet.
+func (h *Heartbeat) Last() (time.Time, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.last, h.lastErr
+}
@@ -2,51 +2,93 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 
+	"service-timetable/internal/auth"
 	"service-timetable/internal/service"
 )
 
 type AdminHandler struct {
 	service *service.TimetableService
+	jobs    JobSource
 }
 
-func NewAdminHandler(svc *service.TimetableService) *AdminHandler {
-	return &AdminHandler{service: svc}
+func NewAdminHandler(svc *service.TimetableService, jobs JobSource) *AdminHandler {
+	return &AdminHandler{service: svc, jobs: jobs}
 }
 
-func (h *AdminHandler) Register(mux *http.ServeMux) {
-	mux.HandleFunc("/admin/timetable/today", h.handleUpdateToday)
+func (h *AdminHandler) Routes() []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/admin/timetable/today", Handler: h.handleGetToday},
+		{Method: http.MethodPost, Path: "/admin/timetable/today", Handler: h.handleUpdateToday},
+		{Method: http.MethodPut, Path: "/admin/timetable/defaults", Handler: h.handleUpsertDefaultSlot},
+		{Method: http.MethodDelete, Path: "/admin/timetable/defaults", Handler: h.handleDeleteDefaultSlot},
+		{Method: http.MethodGet, Path: "/admin/timetable/settings", Handler: h.handleGetSettings},
+		{Method: http.MethodPut, Path: "/admin/timetable/settings", Handler: h.handleUpdateSettings},
+		{Method: http.MethodPost, Path: "/admin/timetable/settings/preview", Handler: h.handlePreviewTemplate},
+		{Method: http.MethodGet, Path: "/admin/timetable/audit", Handler: h.handleListAudit},
+		{Method: http.MethodGet, Path: "/admin/timetable/delegations", Handler: h.handleListDelegations},
+		{Method: http.MethodPost, Path: "/admin/timetable/delegations", Handler: h.handleGrantDelegation},
+		{Method: http.MethodDelete, Path: "/admin/timetable/delegations", Handler: h.handleRevokeDelegation},
+		{Method: http.MethodPost, Path: "/admin/timetable/locks", Handler: h.handleLockDay},
+		{Method: http.MethodDelete, Path: "/admin/timetable/locks", Handler: h.handleUnlockDay},
+		{Method: http.MethodGet, Path: "/admin/timetable/edit-policy", Handler: h.handleGetEditPolicy},
+		{Method: http.MethodPut, Path: "/admin/timetable/edit-policy", Handler: h.handleUpdateEditPolicy},
+		{Method: http.MethodGet, Path: "/admin/timetable/outbox", Handler: h.handleListOutbox},
+		{Method: http.MethodGet, Path: "/admin/api-keys", Handler: h.handleListAPIKeys},
+		{Method: http.MethodPost, Path: "/admin/api-keys", Handler: h.handleIssueAPIKey},
+		{Method: http.MethodDelete, Path: "/admin/api-keys", Handler: h.handleRevokeAPIKey},
+		{Method: http.MethodGet, Path: "/admin/jobs", Handler: h.handleListJobs},
+	}
 }
 
 type updateTodayRequest struct {
-	ClassID    string `json:"class_id"`
-	SlotIndex  int    `json:"slot_index"`
-	CourseCode string `json:"course_code"`
-	StartTime  string `json:"start_time"`
-	EndTime    string `json:"end_time"`
-	Venue      string `json:"venue"`
-	Status     string `json:"status"`
+	ClassID      string `json:"class_id"`
+	SlotIndex    int    `json:"slot_index"`
+	CourseCode   string `json:"course_code"`
+	StartTime    string `json:"start_time"`
+	EndTime      string `json:"end_time"`
+	Venue        string `json:"venue"`
+	FacultyID    string `json:"faculty_id"`
+	Status       string `json:"status"`
+	BypassReason string `json:"bypass_reason"`
 }
 
-func (h *AdminHandler) handleUpdateToday(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+func (h *AdminHandler) handleGetToday(w http.ResponseWriter, r *http.Request) {
+	classID, err := uuid.Parse(r.URL.Query().Get("class_id"))
+	if err != nil {
+		writeServiceError(w, invalidUUID("class_id"))
 		return
 	}
 
-	userIDHeader := r.Header.Get("X-User-ID")
-	if userIDHeader == "" {
-		writeError(w, http.StatusBadRequest)
+	day, err := h.service.GetTodayResolvedDay(r.Context(), classID)
+	if err != nil {
+		writeServiceError(w, err)
 		return
 	}
-	requesterID, err := uuid.Parse(userIDHeader)
+
+	w.Header().Set("ETag", formatETag(day.Version))
+	writeJSON(w, http.StatusOK, resolvedDayToResponse(day))
+}
+
+func (h *AdminHandler) handleUpdateToday(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest)
+		writeServiceError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	expectedVersion, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		invalid.Add("If-Match", "must be a single quoted version")
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
 		return
 	}
 
@@ -54,28 +96,32 @@ func (h *AdminHandler) handleUpdateToday(w http.ResponseWriter, r *http.Request)
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest)
+		writeDecodeError(w, err)
 		return
 	}
 
 	classID, err := uuid.Parse(req.ClassID)
 	if err != nil {
-		writeError(w, http.StatusBadRequest)
-		return
+		invalid.Add("class_id", "must be a UUID")
 	}
-
 	startTime, err := parseTimeOptional(req.StartTime)
 	if err != nil {
-		writeError(w, http.StatusBadRequest)
-		return
+		invalid.Add("start_time", timeFormatMessage)
 	}
 	endTime, err := parseTimeOptional(req.EndTime)
 	if err != nil {
-		writeError(w, http.StatusBadRequest)
+		invalid.Add("end_time", timeFormatMessage)
+	}
+	facultyID, err := parseUUIDOptional(req.FacultyID)
+	if err != nil {
+		invalid.Add("faculty_id", "must be a UUID")
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
 		return
 	}
 
-	err = h.service.UpdateTodayOverride(
+	version, err := h.service.UpdateTodayOverride(
 		r.Context(),
 		requesterID,
 		classID,
@@ -84,40 +130,162 @@ func (h *AdminHandler) handleUpdateToday(w http.ResponseWriter, r *http.Request)
 		startTime,
 		endTime,
 		req.Venue,
+		facultyID,
 		req.Status,
+		req.BypassReason,
+		expectedVersion,
 	)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrInvalidInput):
-			writeError(w, http.StatusBadRequest)
-		case errors.Is(err, service.ErrUnauthorized):
-			writeError(w, http.StatusForbidden)
-		case errors.Is(err, service.ErrNotFound):
-			writeError(w, http.StatusNotFound)
-		case errors.Is(err, service.ErrConflict):
-			writeError(w, http.StatusConflict)
-		default:
-			writeError(w, http.StatusInternalServerError)
-		}
+		writeServiceError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", formatETag(version))
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func writeError(w http.ResponseWriter, status int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, _ = w.Write([]byte("{}"))
+type upsertDefaultSlotRequest struct {
+	ClassID    string `json:"class_id"`
+	Weekday    int    `json:"weekday"`
+	CourseCode string `json:"course_code"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	Venue      string `json:"venue"`
+	FacultyID  string `json:"faculty_id"`
+}
+
+func (h *AdminHandler) handleUpsertDefaultSlot(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var req upsertDefaultSlotRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	classID, err := uuid.Parse(req.ClassID)
+	if err != nil {
+		invalid.Add("class_id", "must be a UUID")
+	}
+	startTime, err := parseTime(req.StartTime)
+	if err != nil {
+		invalid.Add("start_time", timeFormatMessage)
+	}
+	endTime, err := parseTime(req.EndTime)
+	if err != nil {
+		invalid.Add("end_time", timeFormatMessage)
+	}
+	facultyID, err := parseUUIDOptional(req.FacultyID)
+	if err != nil {
+		invalid.Add("faculty_id", "must be a UUID")
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	err = h.service.UpsertDefaultSlot(
+		r.Context(),
+		requesterID,
+		classID,
+		req.Weekday,
+		req.CourseCode,
+		startTime,
+		endTime,
+		req.Venue,
+		facultyID,
+	)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) handleDeleteDefaultSlot(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	query := r.URL.Query()
+	classID, err := uuid.Parse(query.Get("class_id"))
+	if err != nil {
+		invalid.Add("class_id", "must be a UUID")
+	}
+	weekday, err := strconv.Atoi(query.Get("weekday"))
+	if err != nil {
+		invalid.Add("weekday", "must be an integer")
+	}
+	startTime, err := parseTime(query.Get("start_time"))
+	if err != nil {
+		invalid.Add("start_time", timeFormatMessage)
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := h.service.DeleteDefaultSlot(r.Context(), requesterID, classID, weekday, startTime); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func requesterFromContext(r *http.Request) (uuid.UUID, error) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return uuid.Nil, auth.ErrUnauthenticated
+	}
+	return principal.UserID, nil
+}
+
+func invalidUUID(field string) error {
+	return &service.ValidationError{Fields: []service.FieldError{{Field: field, Message: "must be a UUID"}}}
 }
 
 func parseTimeOptional(value string) (*time.Time, error) {
 	if value == "" {
 		return nil, nil
 	}
-	parsed, err := time.ParseInLocation("15:04", value, time.Local)
+	parsed, err := parseTime(value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func parseUUIDOptional(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := uuid.Parse(value)
 	if err != nil {
 		return nil, err
 	}
 	return &parsed, nil
 }
+
+const timeFormatMessage = "must be a time in HH:MM format"
+
+func parseTime(value string) (time.Time, error) {
+	return time.ParseInLocation("15:04", value, time.Local)
+}
+
+const dateFormatMessage = "must be a date in YYYY-MM-DD format"
+
+func parseDate(value string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
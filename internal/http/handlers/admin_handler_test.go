@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/auth"
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+	"service-timetable/internal/service"
+)
+
+var testClassID = uuid.MustParse("0b6f6f0e-8a55-4a5e-9f3c-1a2b3c4d5e01")
+
+// defaultsRevision is added to the day version in every ETag, so tests
+// notice when the two are not combined.
+const defaultsRevision = 3
+
+type adminIdentity struct{}
+
+func (adminIdentity) GetMe(ctx context.Context, userID uuid.UUID) (service.IdentityUser, error) {
+	return service.IdentityUser{ID: userID, Roles: []service.IdentityRole{{Name: "admin"}}}, nil
+}
+
+type fakeTxManager struct {
+	repos repository.TxRepositories
+}
+
+func (m fakeTxManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.TxRepositories) error) error {
+	return fn(ctx, m.repos)
+}
+
+type fakeRevisions struct {
+	repository.DefaultRevisionRepository
+}
+
+func (fakeRevisions) Get(ctx context.Context, classID uuid.UUID, weekday int) (int64, error) {
+	return defaultsRevision, nil
+}
+
+func (fakeRevisions) Share(ctx context.Context, classID uuid.UUID, weekday int) (int64, error) {
+	return defaultsRevision, nil
+}
+
+type fakeDayVersions struct {
+	repository.DayVersionRepository
+	version *int64
+}
+
+func (f fakeDayVersions) Get(ctx context.Context, classID uuid.UUID, date time.Time) (int64, error) {
+	return *f.version, nil
+}
+
+func (f fakeDayVersions) Bump(ctx context.Context, classID uuid.UUID, date time.Time) (int64, error) {
+	*f.version++
+	return *f.version, nil
+}
+
+func (f fakeDayVersions) BumpIfMatch(ctx context.Context, classID uuid.UUID, date time.Time, expected int64) (int64, bool, error) {
+	if expected != *f.version {
+		return 0, false, nil
+	}
+	*f.version++
+	return *f.version, true, nil
+}
+
+type fakeDefaultSlots struct {
+	repository.DefaultSlotRepository
+}
+
+func (fakeDefaultSlots) ListByWeekday(ctx context.Context, classID uuid.UUID, weekday int) ([]domain.DefaultSlot, error) {
+	nine := time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)
+	return []domain.DefaultSlot{
+		{ClassID: classID, Weekday: weekday, SlotIndex: 1, CourseCode: "CS101", StartTime: nine, EndTime: nine.Add(time.Hour), Venue: "R1"},
+	}, nil
+}
+
+type fakeOverrides struct {
+	repository.DailyOverrideRepository
+	upserted *int
+}
+
+func (fakeOverrides) ListByDate(ctx context.Context, classID uuid.UUID, date time.Time) ([]domain.DailyOverride, error) {
+	return nil, nil
+}
+
+func (f fakeOverrides) Upsert(ctx context.Context, override domain.DailyOverride) error {
+	*f.upserted++
+	return nil
+}
+
+type fakeDayLocks struct {
+	repository.DayLockRepository
+}
+
+func (fakeDayLocks) ListByDate(ctx context.Context, classID uuid.UUID, date time.Time) ([]domain.DayLock, error) {
+	return nil, nil
+}
+
+type fakeEditPolicies struct {
+	repository.EditPolicyRepository
+}
+
+func (fakeEditPolicies) Get(ctx context.Context, classID uuid.UUID) (domain.EditPolicy, error) {
+	return domain.EditPolicy{ClassID: classID}, nil
+}
+
+type fakeAudit struct {
+	repository.AuditRepository
+}
+
+func (fakeAudit) Insert(ctx context.Context, entry domain.AuditEntry) error {
+	return nil
+}
+
+type fakeSettings struct {
+	repository.AnnouncementSettingsRepository
+}
+
+func (fakeSettings) GetByClassID(ctx context.Context, classID uuid.UUID) (domain.AnnouncementSettings, error) {
+	return domain.AnnouncementSettings{}, sql.ErrNoRows
+}
+
+// newVersionedAdminHandler returns a handler over a day at version and a
+// counter of the overrides written to it.
+func newVersionedAdminHandler(version int64) (*AdminHandler, *int) {
+	upserted := new(int)
+	repos := repository.TxRepositories{
+		Revisions:    fakeRevisions{},
+		DayVersions:  fakeDayVersions{version: &version},
+		DefaultSlots: fakeDefaultSlots{},
+		Overrides:    fakeOverrides{upserted: upserted},
+		DayLocks:     fakeDayLocks{},
+		EditPolicies: fakeEditPolicies{},
+		Audit:        fakeAudit{},
+		Settings:     fakeSettings{},
+	}
+	svc := service.NewTimetableService(fakeTxManager{repos: repos}, adminIdentity{}, service.DefaultPermissions())
+	return NewAdminHandler(svc, nil), upserted
+}
+
+func adminRequest(method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	principal := auth.Principal{UserID: uuid.New(), Source: auth.SourceTrustedGateway}
+	return r.WithContext(auth.WithPrincipal(r.Context(), principal))
+}
+
+func TestUpdateTodayIfMatch(t *testing.T) {
+	handler, _ := newVersionedAdminHandler(2)
+	w := httptest.NewRecorder()
+	handler.handleGetToday(w, adminRequest(http.MethodGet, "/admin/timetable/today?class_id="+testClassID.String(), ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	current := w.Header().Get("ETag")
+	if current != `"5"` {
+		t.Fatalf("ETag = %s, want %q", current, `"5"`)
+	}
+
+	body := `{"class_id":"` + testClassID.String() + `","slot_index":1,"course_code":"CS101","start_time":"10:00","end_time":"11:00","venue":"R2","status":"replaced"}`
+	tests := []struct {
+		name         string
+		ifMatch      string
+		wantStatus   int
+		wantCode     string
+		wantETag     string
+		wantUpserted int
+	}{
+		{name: "malformed", ifMatch: "5", wantStatus: http.StatusBadRequest, wantCode: codeInvalidInput},
+		{name: "negative", ifMatch: `"-1"`, wantStatus: http.StatusBadRequest, wantCode: codeInvalidInput},
+		{name: "list of tags", ifMatch: `"5", "6"`, wantStatus: http.StatusBadRequest, wantCode: codeInvalidInput},
+		{name: "stale", ifMatch: `"4"`, wantStatus: http.StatusConflict, wantCode: codeVersionMismatch},
+		{name: "older than the defaults", ifMatch: `"1"`, wantStatus: http.StatusConflict, wantCode: codeVersionMismatch},
+		{name: "matching", ifMatch: current, wantStatus: http.StatusNoContent, wantETag: `"6"`, wantUpserted: 1},
+		{name: "matching weak tag", ifMatch: "W/" + current, wantStatus: http.StatusNoContent, wantETag: `"6"`, wantUpserted: 1},
+		{name: "unconditional", ifMatch: "*", wantStatus: http.StatusNoContent, wantETag: `"6"`, wantUpserted: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, upserted := newVersionedAdminHandler(2)
+			r := adminRequest(http.MethodPost, "/admin/timetable/today", body)
+			r.Header.Set("If-Match", tt.ifMatch)
+			w := httptest.NewRecorder()
+
+			handler.handleUpdateToday(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %s", w.Body, tt.wantCode)
+			}
+			if tt.wantETag != "" && w.Header().Get("ETag") != tt.wantETag {
+				t.Errorf("ETag = %s, want %s", w.Header().Get("ETag"), tt.wantETag)
+			}
+			if *upserted != tt.wantUpserted {
+				t.Errorf("overrides written = %d, want %d", *upserted, tt.wantUpserted)
+			}
+		})
+	}
+}
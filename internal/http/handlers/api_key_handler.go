@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/service"
+)
+
+type apiKeyResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ClassIDs   []string `json:"class_ids"`
+	CreatedBy  string   `json:"created_by"`
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  *string  `json:"expires_at"`
+	RevokedAt  *string  `json:"revoked_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+}
+
+type issuedAPIKeyResponse struct {
+	apiKeyResponse
+	Key string `json:"key"`
+}
+
+type apiKeyListResponse struct {
+	Keys []apiKeyResponse `json:"keys"`
+}
+
+func apiKeyToResponse(key domain.APIKey) apiKeyResponse {
+	classIDs := make([]string, 0, len(key.ClassIDs))
+	for _, classID := range key.ClassIDs {
+		classIDs = append(classIDs, classID.String())
+	}
+	return apiKeyResponse{
+		ID:         key.ID.String(),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ClassIDs:   classIDs,
+		CreatedBy:  key.CreatedBy.String(),
+		CreatedAt:  key.CreatedAt.UTC().Format(time.RFC3339),
+		ExpiresAt:  formatOptionalTimestamp(key.ExpiresAt),
+		RevokedAt:  formatOptionalTimestamp(key.RevokedAt),
+		LastUsedAt: formatOptionalTimestamp(key.LastUsedAt),
+	}
+}
+
+type issueAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ClassIDs  []string `json:"class_ids"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+func (h *AdminHandler) handleIssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var req issueAPIKeyRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	classIDs := make([]uuid.UUID, 0, len(req.ClassIDs))
+	for _, value := range req.ClassIDs {
+		classID, err := uuid.Parse(value)
+		if err != nil {
+			invalid.Add("class_ids", "must be UUIDs")
+			break
+		}
+		classIDs = append(classIDs, classID)
+	}
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		parsed, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			invalid.Add("expires_at", "must be an RFC 3339 timestamp")
+		}
+		expiresAt = &parsed
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	key, secret, err := h.service.IssueAPIKey(r.Context(), requesterID, req.Name, req.Scopes, classIDs, expiresAt)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, issuedAPIKeyResponse{apiKeyResponse: apiKeyToResponse(key), Key: secret})
+}
+
+func (h *AdminHandler) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	keys, err := h.service.ListAPIKeys(r.Context(), requesterID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := apiKeyListResponse{Keys: make([]apiKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		response.Keys = append(response.Keys, apiKeyToResponse(key))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *AdminHandler) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	keyID, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		writeServiceError(w, invalidUUID("id"))
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), requesterID, keyID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func formatOptionalTimestamp(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.UTC().Format(time.RFC3339)
+	return &formatted
+}
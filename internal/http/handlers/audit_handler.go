@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/service"
+)
+
+type auditEntryResponse struct {
+	ID         string          `json:"id"`
+	OccurredAt string          `json:"occurred_at"`
+	ActorID    string          `json:"actor_id"`
+	ActorKind  string          `json:"actor_kind"`
+	Action     string          `json:"action"`
+	ClassID    *string         `json:"class_id"`
+	Date       *string         `json:"date"`
+	Details    json.RawMessage `json:"details"`
+}
+
+type auditResponse struct {
+	Entries []auditEntryResponse `json:"entries"`
+}
+
+func auditToResponse(entries []domain.AuditEntry) auditResponse {
+	response := auditResponse{Entries: make([]auditEntryResponse, 0, len(entries))}
+	for _, entry := range entries {
+		item := auditEntryResponse{
+			ID:         entry.ID.String(),
+			OccurredAt: entry.OccurredAt.UTC().Format(time.RFC3339),
+			ActorID:    entry.ActorID.String(),
+			ActorKind:  entry.ActorKind,
+			Action:     entry.Action,
+			Details:    entry.Details,
+		}
+		if entry.ClassID != uuid.Nil {
+			classID := entry.ClassID.String()
+			item.ClassID = &classID
+		}
+		if entry.Date != nil {
+			date := entry.Date.Format("2006-01-02")
+			item.Date = &date
+		}
+		response.Entries = append(response.Entries, item)
+	}
+	return response
+}
+
+func (h *AdminHandler) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	query := r.URL.Query()
+	var classID uuid.UUID
+	if value := query.Get("class_id"); value != "" {
+		if classID, err = uuid.Parse(value); err != nil {
+			invalid.Add("class_id", "must be a UUID")
+		}
+	}
+	var limit int
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			invalid.Add("limit", "must be an integer")
+		}
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	entries, err := h.service.ListAudit(r.Context(), requesterID, classID, limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, auditToResponse(entries))
+}
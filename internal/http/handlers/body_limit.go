@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// maxRequestBodyBytes bounds every request body, and so what the JSON
+// decoders, the rate limiter and idempotency fingerprints read.
+const maxRequestBodyBytes = 1 << 20
+
+// WithBodyLimit caps request bodies at maxRequestBodyBytes. Reads past the
+// limit fail with *http.MaxBytesError, which is answered with 413.
+func WithBodyLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/service"
+)
+
+type delegationResponse struct {
+	ID         string `json:"id"`
+	ClassID    string `json:"class_id"`
+	DelegateID string `json:"delegate_id"`
+	GrantedBy  string `json:"granted_by"`
+	StartsOn   string `json:"starts_on"`
+	EndsOn     string `json:"ends_on"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type delegationListResponse struct {
+	Delegations []delegationResponse `json:"delegations"`
+}
+
+func delegationToResponse(delegation domain.Delegation) delegationResponse {
+	return delegationResponse{
+		ID:         delegation.ID.String(),
+		ClassID:    delegation.ClassID.String(),
+		DelegateID: delegation.DelegateID.String(),
+		GrantedBy:  delegation.GrantedBy.String(),
+		StartsOn:   delegation.StartsOn.Format("2006-01-02"),
+		EndsOn:     delegation.EndsOn.Format("2006-01-02"),
+		CreatedAt:  delegation.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+type grantDelegationRequest struct {
+	ClassID    string `json:"class_id"`
+	DelegateID string `json:"delegate_id"`
+	StartsOn   string `json:"starts_on"`
+	EndsOn     string `json:"ends_on"`
+}
+
+func (h *AdminHandler) handleGrantDelegation(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var req grantDelegationRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	classID, err := uuid.Parse(req.ClassID)
+	if err != nil {
+		invalid.Add("class_id", "must be a UUID")
+	}
+	delegateID, err := uuid.Parse(req.DelegateID)
+	if err != nil {
+		invalid.Add("delegate_id", "must be a UUID")
+	}
+	startsOn, err := parseDate(req.StartsOn)
+	if err != nil {
+		invalid.Add("starts_on", dateFormatMessage)
+	}
+	endsOn, err := parseDate(req.EndsOn)
+	if err != nil {
+		invalid.Add("ends_on", dateFormatMessage)
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	delegation, err := h.service.GrantDelegation(r.Context(), requesterID, classID, delegateID, startsOn, endsOn)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, delegationToResponse(delegation))
+}
+
+func (h *AdminHandler) handleListDelegations(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	classID, err := uuid.Parse(r.URL.Query().Get("class_id"))
+	if err != nil {
+		writeServiceError(w, invalidUUID("class_id"))
+		return
+	}
+
+	delegations, err := h.service.ListDelegations(r.Context(), requesterID, classID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := delegationListResponse{Delegations: make([]delegationResponse, 0, len(delegations))}
+	for _, delegation := range delegations {
+		response.Delegations = append(response.Delegations, delegationToResponse(delegation))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *AdminHandler) handleRevokeDelegation(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	delegationID, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		writeServiceError(w, invalidUUID("id"))
+		return
+	}
+
+	if err := h.service.RevokeDelegation(r.Context(), requesterID, delegationID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+)
+
+type editPolicyResponse struct {
+	ClassID       string  `json:"class_id"`
+	CutoffMinutes *int    `json:"cutoff_minutes"`
+	UpdatedAt     *string `json:"updated_at"`
+}
+
+func editPolicyToResponse(policy domain.EditPolicy) editPolicyResponse {
+	response := editPolicyResponse{
+		ClassID:       policy.ClassID.String(),
+		CutoffMinutes: policy.CutoffMinutes,
+	}
+	if !policy.UpdatedAt.IsZero() {
+		updatedAt := policy.UpdatedAt.UTC().Format(time.RFC3339)
+		response.UpdatedAt = &updatedAt
+	}
+	return response
+}
+
+func (h *AdminHandler) handleGetEditPolicy(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	classID, err := uuid.Parse(r.URL.Query().Get("class_id"))
+	if err != nil {
+		writeServiceError(w, invalidUUID("class_id"))
+		return
+	}
+
+	policy, err := h.service.GetEditPolicy(r.Context(), requesterID, classID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, editPolicyToResponse(policy))
+}
+
+type updateEditPolicyRequest struct {
+	ClassID       string `json:"class_id"`
+	CutoffMinutes *int   `json:"cutoff_minutes"`
+}
+
+func (h *AdminHandler) handleUpdateEditPolicy(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var req updateEditPolicyRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	classID, err := uuid.Parse(req.ClassID)
+	if err != nil {
+		writeServiceError(w, invalidUUID("class_id"))
+		return
+	}
+
+	policy, err := h.service.UpdateEditPolicy(r.Context(), requesterID, classID, req.CutoffMinutes)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, editPolicyToResponse(policy))
+}
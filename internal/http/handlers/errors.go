@@ -1,3 +1,250 @@
 package handlers
 
-// Intentionally left blank for future structured error responses.
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"service-timetable/internal/auth"
+	"service-timetable/internal/service"
+)
+
+const (
+	codeInvalidInput           = "invalid_input"
+	codeInvalidJSON            = "invalid_json"
+	codeRequestTooLarge        = "request_too_large"
+	codeUnauthenticated        = "unauthenticated"
+	codeForbidden              = "forbidden"
+	codeNotFound               = "not_found"
+	codeConflict               = "conflict"
+	codeSlotConflict           = "slot_conflict"
+	codeVersionMismatch        = "version_mismatch"
+	codeDayLocked              = "day_locked"
+	codeEditCutoff             = "edit_cutoff_passed"
+	codeIdempotencyKeyReused   = "idempotency_key_reused"
+	codeIdempotencyKeyInFlight = "idempotency_key_in_flight"
+	codeRateLimited            = "rate_limited"
+	codeMethodNotAllowed       = "method_not_allowed"
+	codeInternal               = "internal_error"
+	codeUnavailable            = "dependency_unavailable"
+)
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Details   []fieldErrorEntry      `json:"details,omitempty"`
+	Conflicts []conflictingSlotEntry `json:"conflicts,omitempty"`
+	Lock      *lockResponse          `json:"lock,omitempty"`
+	Cutoff    *cutoffEntry           `json:"cutoff,omitempty"`
+}
+
+type fieldErrorEntry struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type conflictingSlotEntry struct {
+	SlotIndex  int    `json:"slot_index"`
+	CourseCode string `json:"course_code"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	Venue      string `json:"venue"`
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, errorResponse{Error: errorBody{Code: code, Message: message}})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	recordError(w, err)
+	var validationErr *service.ValidationError
+	var conflictErr *service.SlotConflictError
+	var lockedErr *service.DayLockedError
+	var cutoffErr *service.EditCutoffError
+	var rateLimitedErr *service.RateLimitedError
+	var tooLargeErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &validationErr):
+		writeValidationError(w, validationErr)
+	case errors.As(err, &conflictErr):
+		writeSlotConflict(w, conflictErr)
+	case errors.As(err, &lockedErr):
+		writeDayLocked(w, lockedErr)
+	case errors.As(err, &cutoffErr):
+		writeEditCutoff(w, cutoffErr)
+	case errors.As(err, &rateLimitedErr):
+		w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(rateLimitedErr.RetryAfter), 1)))
+		writeError(w, http.StatusTooManyRequests, codeRateLimited, rateLimitedErr.Error())
+	case errors.As(err, &tooLargeErr):
+		writeTooLarge(w, tooLargeErr)
+	case errors.Is(err, auth.ErrUnauthenticated):
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, codeUnauthenticated, err.Error())
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		writeError(w, http.StatusUnprocessableEntity, codeIdempotencyKeyReused, "idempotency key was already used for a different request")
+	case errors.Is(err, service.ErrInvalidInput):
+		writeError(w, http.StatusBadRequest, codeInvalidInput, "invalid input")
+	case errors.Is(err, service.ErrUnauthorized):
+		writeError(w, http.StatusForbidden, codeForbidden, "requester is not allowed to perform this action")
+	case errors.Is(err, service.ErrNotFound):
+		writeError(w, http.StatusNotFound, codeNotFound, "requester or referenced entity not found")
+	case errors.Is(err, service.ErrIdempotencyKeyInFlight):
+		writeError(w, http.StatusConflict, codeIdempotencyKeyInFlight, "a request with this idempotency key is still in progress, retry later")
+	case errors.Is(err, service.ErrVersionMismatch):
+		writeError(w, http.StatusConflict, codeVersionMismatch, "If-Match does not match the current version")
+	case errors.Is(err, service.ErrConflict):
+		writeError(w, http.StatusConflict, codeConflict, "conflicting change")
+	case errors.Is(err, service.ErrCircuitOpen):
+		writeError(w, http.StatusServiceUnavailable, codeUnavailable, "a dependency is unavailable, retry later")
+	default:
+		writeError(w, http.StatusInternalServerError, codeInternal, "internal error")
+	}
+}
+
+func writeValidationError(w http.ResponseWriter, validationErr *service.ValidationError) {
+	body := errorBody{
+		Code:    codeInvalidInput,
+		Message: validationErr.Error(),
+		Details: make([]fieldErrorEntry, 0, len(validationErr.Fields)),
+	}
+	for _, field := range validationErr.Fields {
+		body.Details = append(body.Details, fieldErrorEntry{Field: field.Field, Message: field.Message})
+	}
+	writeJSON(w, http.StatusBadRequest, errorResponse{Error: body})
+}
+
+func writeSlotConflict(w http.ResponseWriter, conflictErr *service.SlotConflictError) {
+	body := errorBody{
+		Code:      codeSlotConflict,
+		Message:   conflictErr.Error(),
+		Conflicts: make([]conflictingSlotEntry, 0, len(conflictErr.Conflicts)),
+	}
+	for _, slot := range conflictErr.Conflicts {
+		body.Conflicts = append(body.Conflicts, conflictingSlotEntry{
+			SlotIndex:  slot.SlotIndex,
+			CourseCode: slot.CourseCode,
+			StartTime:  formatClock(slot.StartTime),
+			EndTime:    formatClock(slot.EndTime),
+			Venue:      slot.Venue,
+		})
+	}
+	writeJSON(w, http.StatusConflict, errorResponse{Error: body})
+}
+
+// writeDecodeError reports a malformed request body, naming the offending
+// field when the decoder exposes it.
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var tooLargeErr *http.MaxBytesError
+	if errors.As(err, &tooLargeErr) {
+		writeTooLarge(w, tooLargeErr)
+		return
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		writeServiceError(w, &service.ValidationError{Fields: []service.FieldError{{
+			Field:   typeErr.Field,
+			Message: "must be " + jsonTypeName(typeErr.Type),
+		}}})
+		return
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		writeServiceError(w, &service.ValidationError{Fields: []service.FieldError{{
+			Field:   strings.Trim(field, `"`),
+			Message: "is not a known field",
+		}}})
+		return
+	}
+	writeError(w, http.StatusBadRequest, codeInvalidJSON, "request body is not valid JSON")
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// jsonTypeName names the JSON type a field of type t is decoded from, so that
+// errors speak of the API rather than of Go types.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		// IDs, dates and times are strings in the API.
+		return "a string"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a valid value"
+	}
+}
+
+// writeBodyReadError reports a request body that could not be read before
+// decoding.
+func writeBodyReadError(w http.ResponseWriter, err error) {
+	var tooLargeErr *http.MaxBytesError
+	if errors.As(err, &tooLargeErr) {
+		writeTooLarge(w, tooLargeErr)
+		return
+	}
+	writeError(w, http.StatusBadRequest, codeInvalidJSON, "request body could not be read")
+}
+
+func writeTooLarge(w http.ResponseWriter, tooLargeErr *http.MaxBytesError) {
+	writeError(w, http.StatusRequestEntityTooLarge, codeRequestTooLarge, fmt.Sprintf("request body must be at most %d bytes", tooLargeErr.Limit))
+}
+
+func writeDayLocked(w http.ResponseWriter, lockedErr *service.DayLockedError) {
+	lock := lockToResponse(lockedErr.Lock)
+	writeJSON(w, http.StatusLocked, errorResponse{Error: errorBody{
+		Code:    codeDayLocked,
+		Message: lockedErr.Error(),
+		Lock:    &lock,
+	}})
+}
+
+type cutoffEntry struct {
+	SlotIndex     int    `json:"slot_index"`
+	SlotStart     string `json:"slot_start"`
+	CutoffAt      string `json:"cutoff_at"`
+	CutoffMinutes int    `json:"cutoff_minutes"`
+	BypassAllowed bool   `json:"bypass_allowed"`
+}
+
+func writeEditCutoff(w http.ResponseWriter, cutoffErr *service.EditCutoffError) {
+	writeJSON(w, http.StatusForbidden, errorResponse{Error: errorBody{
+		Code:    codeEditCutoff,
+		Message: cutoffErr.Error(),
+		Cutoff: &cutoffEntry{
+			SlotIndex:     cutoffErr.SlotIndex,
+			SlotStart:     cutoffErr.SlotStart.Format(time.RFC3339),
+			CutoffAt:      cutoffErr.CutoffAt.Format(time.RFC3339),
+			CutoffMinutes: cutoffErr.CutoffMinutes,
+			BypassAllowed: cutoffErr.BypassAllowed,
+		},
+	}})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWriteDecodeErrorNamesJSONTypes(t *testing.T) {
+	type request struct {
+		ClassID  uuid.UUID         `json:"class_id"`
+		Date     *time.Time        `json:"date"`
+		Weekday  int               `json:"weekday"`
+		Minutes  *int              `json:"minutes"`
+		Ratio    float64           `json:"ratio"`
+		Venue    string            `json:"venue"`
+		Enabled  bool              `json:"enabled"`
+		Scopes   []string          `json:"scopes"`
+		Settings map[string]string `json:"settings"`
+		Slot     struct{}          `json:"slot"`
+	}
+
+	tests := []struct {
+		body        string
+		wantField   string
+		wantMessage string
+	}{
+		{body: `{"class_id":1}`, wantField: "class_id", wantMessage: "must be a string"},
+		{body: `{"date":1}`, wantField: "date", wantMessage: "must be a string"},
+		{body: `{"weekday":"monday"}`, wantField: "weekday", wantMessage: "must be an integer"},
+		{body: `{"weekday":1.5}`, wantField: "weekday", wantMessage: "must be an integer"},
+		{body: `{"minutes":"30"}`, wantField: "minutes", wantMessage: "must be an integer"},
+		{body: `{"ratio":"half"}`, wantField: "ratio", wantMessage: "must be a number"},
+		{body: `{"venue":101}`, wantField: "venue", wantMessage: "must be a string"},
+		{body: `{"enabled":"yes"}`, wantField: "enabled", wantMessage: "must be a boolean"},
+		{body: `{"scopes":"read"}`, wantField: "scopes", wantMessage: "must be an array"},
+		{body: `{"scopes":[1]}`, wantField: "scopes.0", wantMessage: "must be a string"},
+		{body: `{"settings":[]}`, wantField: "settings", wantMessage: "must be an object"},
+		{body: `{"slot":"first"}`, wantField: "slot", wantMessage: "must be an object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			var req request
+			err := json.NewDecoder(strings.NewReader(tt.body)).Decode(&req)
+			if err == nil {
+				t.Fatal("Decode succeeded, want an error")
+			}
+			w := httptest.NewRecorder()
+			writeDecodeError(w, err)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			var resp errorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Error.Code != codeInvalidInput || len(resp.Error.Details) != 1 {
+				t.Fatalf("error = %+v, want one %s detail", resp.Error, codeInvalidInput)
+			}
+			if detail := resp.Error.Details[0]; detail.Field != tt.wantField || detail.Message != tt.wantMessage {
+				t.Errorf("detail = %s %q, want %s %q", detail.Field, detail.Message, tt.wantField, tt.wantMessage)
+			}
+		})
+	}
+}
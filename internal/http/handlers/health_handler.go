@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"service-timetable/internal/health"
+)
+
+type ReadinessSource interface {
+	Ready(ctx context.Context) health.Report
+}
+
+// HealthHandler serves the Kubernetes liveness and readiness probes.
+type HealthHandler struct {
+	readiness ReadinessSource
+}
+
+func NewHealthHandler(readiness ReadinessSource) *HealthHandler {
+	return &HealthHandler{readiness: readiness}
+}
+
+func (h *HealthHandler) Routes() []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/healthz", Handler: h.handleLiveness},
+		{Method: http.MethodGet, Path: "/readyz", Handler: h.handleReadiness},
+	}
+}
+
+type healthResponse struct {
+	Status string                         `json:"status"`
+	Checks map[string]healthCheckResponse `json:"checks,omitempty"`
+}
+
+type healthCheckResponse struct {
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+func (h *HealthHandler) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: health.StatusOK})
+}
+
+func (h *HealthHandler) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	report := h.readiness.Ready(r.Context())
+	response := healthResponse{Status: report.Status, Checks: make(map[string]healthCheckResponse, len(report.Checks))}
+	for name, result := range report.Checks {
+		response.Checks[name] = healthCheckResponse{
+			Status:     result.Status,
+			Error:      result.Error,
+			DurationMS: result.Duration.Milliseconds(),
+			Details:    result.Details,
+		}
+	}
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
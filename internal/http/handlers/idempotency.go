@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"service-timetable/internal/service"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// withIdempotencyKey passes the Idempotency-Key header of write requests to
+// the service. The fingerprint covers method, path, query and body so that a
+// key reused for a different request is rejected instead of replayed.
+func withIdempotencyKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeServiceError(w, &service.ValidationError{Fields: []service.FieldError{{
+				Field:   "Idempotency-Key",
+				Message: "must be at most 255 characters",
+			}}})
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeBodyReadError(w, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+		hash.Write(body)
+
+		ctx := service.WithIdempotencyKey(r.Context(), service.IdempotencyKey{
+			Key:         key,
+			Fingerprint: hex.EncodeToString(hash.Sum(nil)),
+		})
+		next(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"service-timetable/internal/scheduler"
+)
+
+// JobSource reports the state of scheduled jobs.
+type JobSource interface {
+	Jobs() []scheduler.JobStatus
+	IsLeader() bool
+}
+
+type jobRunResponse struct {
+	ScheduledAt string `json:"scheduled_at"`
+	StartedAt   string `json:"started_at"`
+	FinishedAt  string `json:"finished_at"`
+	DurationMS  int64  `json:"duration_ms"`
+	Outcome     string `json:"outcome"`
+	Error       string `json:"error,omitempty"`
+}
+
+type jobResponse struct {
+	Name            string          `json:"name"`
+	Schedule        string          `json:"schedule"`
+	TimeoutSeconds  int             `json:"timeout_seconds"`
+	Running         bool            `json:"running"`
+	NextRun         string          `json:"next_run"`
+	LastRun         *jobRunResponse `json:"last_run"`
+	Runs            int             `json:"runs"`
+	Failures        int             `json:"failures"`
+	SkippedOverlaps int             `json:"skipped_overlaps"`
+}
+
+type jobListResponse struct {
+	Leader bool          `json:"leader"`
+	Jobs   []jobResponse `json:"jobs"`
+}
+
+func jobsToResponse(leader bool, jobs []scheduler.JobStatus) jobListResponse {
+	response := jobListResponse{Leader: leader, Jobs: make([]jobResponse, 0, len(jobs))}
+	for _, job := range jobs {
+		item := jobResponse{
+			Name:            job.Name,
+			Schedule:        job.Schedule,
+			TimeoutSeconds:  int(job.Timeout.Seconds()),
+			Running:         job.Running,
+			NextRun:         job.NextRun.UTC().Format(time.RFC3339),
+			Runs:            job.Runs,
+			Failures:        job.Failures,
+			SkippedOverlaps: job.SkippedOverlaps,
+		}
+		if last := job.LastRun; last != nil {
+			item.LastRun = &jobRunResponse{
+				ScheduledAt: last.ScheduledAt.UTC().Format(time.RFC3339),
+				StartedAt:   last.StartedAt.UTC().Format(time.RFC3339Nano),
+				FinishedAt:  last.FinishedAt.UTC().Format(time.RFC3339Nano),
+				DurationMS:  last.FinishedAt.Sub(last.StartedAt).Milliseconds(),
+				Outcome:     last.Outcome,
+				Error:       last.Error,
+			}
+		}
+		response.Jobs = append(response.Jobs, item)
+	}
+	return response
+}
+
+func (h *AdminHandler) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if err := h.service.AuthorizeJobStatus(r.Context(), requesterID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jobsToResponse(h.jobs.IsLeader(), h.jobs.Jobs()))
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/service"
+)
+
+type lockDayRequest struct {
+	ClassID   string `json:"class_id"`
+	Date      string `json:"date"`
+	SlotIndex int    `json:"slot_index"`
+	Reason    string `json:"reason"`
+}
+
+func (h *AdminHandler) handleLockDay(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var req lockDayRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	classID, err := uuid.Parse(req.ClassID)
+	if err != nil {
+		invalid.Add("class_id", "must be a UUID")
+	}
+	date, err := parseDate(req.Date)
+	if err != nil {
+		invalid.Add("date", dateFormatMessage)
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	version, err := h.service.LockDay(r.Context(), requesterID, classID, date, req.SlotIndex, req.Reason)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) handleUnlockDay(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	query := r.URL.Query()
+	classID, err := uuid.Parse(query.Get("class_id"))
+	if err != nil {
+		invalid.Add("class_id", "must be a UUID")
+	}
+	date, err := parseDate(query.Get("date"))
+	if err != nil {
+		invalid.Add("date", dateFormatMessage)
+	}
+	var slotIndex int
+	if value := query.Get("slot_index"); value != "" {
+		if slotIndex, err = strconv.Atoi(value); err != nil {
+			invalid.Add("slot_index", "must be an integer")
+		}
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	version, err := h.service.UnlockDay(r.Context(), requesterID, classID, date, slotIndex)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
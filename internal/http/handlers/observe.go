@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"service-timetable/internal/logging"
+	"service-timetable/internal/metrics"
+	"service-timetable/internal/tracing"
+)
+
+const (
+	RequestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+// WithObservability gives every request a request ID and a server span,
+// continuing the caller's trace when it sends one, logs the request once
+// served and records its status and latency under its route path. Paths that are
+// not routes are recorded as "other", so that probing clients cannot create
+// unbounded metric labels.
+func WithObservability(paths []string, next http.Handler) http.Handler {
+	known := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		known[path] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		route := r.URL.Path
+		if !known[route] {
+			route = "other"
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("request.id", requestID),
+			),
+		)
+		defer span.End()
+		ctx = logging.WithRequestID(ctx, requestID)
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			ctx = logging.With(ctx, slog.String("trace_id", spanContext.TraceID().String()))
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		elapsed := time.Since(start)
+
+		metrics.ObserveHTTPRequest(route, r.Method, recorder.status, elapsed)
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+		if recorder.err != nil {
+			span.RecordError(recorder.err)
+		}
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.status),
+			slog.Int64("duration_ms", elapsed.Milliseconds()),
+		}
+		if recorder.err != nil {
+			attrs = append(attrs, slog.String("error", recorder.err.Error()))
+		}
+		slog.LogAttrs(ctx, level, "http request", attrs...)
+	})
+}
+
+// validRequestID accepts client request IDs that are safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// statusRecorder captures the response status, and the error behind it when
+// written by writeServiceError.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	err         error
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func recordError(w http.ResponseWriter, err error) {
+	if recorder, ok := w.(*statusRecorder); ok {
+		recorder.err = err
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/service"
+)
+
+type outboxEventResponse struct {
+	ID        string          `json:"id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt string          `json:"created_at"`
+	Published bool            `json:"published"`
+}
+
+type outboxResponse struct {
+	Events []outboxEventResponse `json:"events"`
+}
+
+func outboxToResponse(events []domain.OutboxEvent) outboxResponse {
+	response := outboxResponse{Events: make([]outboxEventResponse, 0, len(events))}
+	for _, event := range events {
+		response.Events = append(response.Events, outboxEventResponse{
+			ID:        event.ID.String(),
+			EventType: event.EventType,
+			Payload:   event.Payload,
+			CreatedAt: event.CreatedAt.UTC().Format(time.RFC3339Nano),
+			Published: event.Published,
+		})
+	}
+	return response
+}
+
+func (h *AdminHandler) handleListOutbox(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	query := r.URL.Query()
+	var afterID *uuid.UUID
+	if value := query.Get("after"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			invalid.Add("after", "must be a UUID")
+		}
+		afterID = &parsed
+	}
+	var limit int
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			invalid.Add("limit", "must be an integer")
+		}
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	events, err := h.service.ListOutboxEvents(r.Context(), requesterID, afterID, limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, outboxToResponse(events))
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/auth"
+	"service-timetable/internal/service"
+)
+
+// WithRateLimit limits write requests per requester and per class before
+// they reach a handler, and so before any identity lookup. It must run after
+// authentication. Reads are not limited.
+func WithRateLimit(limiter *service.RateLimiter, next http.Handler) http.Handler {
+	if limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		classID, err := requestClassID(r)
+		if err != nil {
+			writeBodyReadError(w, err)
+			return
+		}
+
+		decision, err := limiter.Take(principal.UserID, classID)
+		if decision.Limit > 0 {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+		}
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestClassID finds the class a write targets in the class_id query
+// parameter or body field. A missing or malformed class_id is left for
+// request validation to report.
+func requestClassID(r *http.Request) (*uuid.UUID, error) {
+	if value := r.URL.Query().Get("class_id"); value != "" {
+		if classID, err := uuid.Parse(value); err == nil {
+			return &classID, nil
+		}
+		return nil, nil
+	}
+	if r.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var target struct {
+		ClassID string `json:"class_id"`
+	}
+	if json.Unmarshal(body, &target) != nil {
+		return nil, nil
+	}
+	if classID, err := uuid.Parse(target.ClassID); err == nil {
+		return &classID, nil
+	}
+	return nil, nil
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
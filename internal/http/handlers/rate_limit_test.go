@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/auth"
+	"service-timetable/internal/service"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	// One write a minute per class, so the second write to a class is
+	// rejected with about a minute to wait.
+	limiter := service.NewRateLimiter(service.RateLimit{PerMinute: 60, Burst: 10}, service.RateLimit{PerMinute: 1})
+	var served int
+	handler := WithRateLimit(limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	classBody := `{"class_id":"` + testClassID.String() + `"}`
+
+	tests := []struct {
+		name          string
+		method        string
+		target        string
+		body          string
+		wantStatus    int
+		wantRemaining string
+		wantReset     string
+		wantServed    int
+	}{
+		{name: "first write to the class", method: http.MethodPost, target: "/admin/timetable/today", body: classBody, wantStatus: http.StatusNoContent, wantRemaining: "0", wantReset: "60", wantServed: 1},
+		{name: "second write to the class", method: http.MethodPost, target: "/admin/timetable/today", body: classBody, wantStatus: http.StatusTooManyRequests, wantRemaining: "0", wantReset: "60", wantServed: 1},
+		{name: "class in the query", method: http.MethodDelete, target: "/admin/timetable/locks?class_id=" + testClassID.String(), wantStatus: http.StatusTooManyRequests, wantRemaining: "0", wantReset: "60", wantServed: 1},
+		{name: "write to another class", method: http.MethodPost, target: "/admin/timetable/today", body: `{"class_id":"` + uuid.NewString() + `"}`, wantStatus: http.StatusNoContent, wantRemaining: "0", wantReset: "60", wantServed: 2},
+		{name: "write without a class", method: http.MethodDelete, target: "/admin/api-keys?id=" + uuid.NewString(), wantStatus: http.StatusNoContent, wantRemaining: "7", wantReset: "3", wantServed: 3},
+		{name: "read", method: http.MethodGet, target: "/admin/timetable/today?class_id=" + testClassID.String(), wantStatus: http.StatusNoContent, wantServed: 4},
+	}
+
+	requesterID := uuid.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			r = r.WithContext(auth.WithPrincipal(r.Context(), auth.Principal{UserID: requesterID, Source: auth.SourceTrustedGateway}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if served != tt.wantServed {
+				t.Errorf("requests served = %d, want %d", served, tt.wantServed)
+			}
+			if got := w.Header().Get("X-RateLimit-Remaining"); got != tt.wantRemaining {
+				t.Errorf("X-RateLimit-Remaining = %q, want %q", got, tt.wantRemaining)
+			}
+			if got := w.Header().Get("X-RateLimit-Reset"); got != tt.wantReset {
+				t.Errorf("X-RateLimit-Reset = %q, want %q", got, tt.wantReset)
+			}
+			if tt.wantStatus != http.StatusTooManyRequests {
+				if got := w.Header().Get("Retry-After"); got != "" {
+					t.Errorf("Retry-After = %q on an accepted request", got)
+				}
+				return
+			}
+			if got := w.Header().Get("Retry-After"); got != "60" {
+				t.Errorf("Retry-After = %q, want %q", got, "60")
+			}
+			if got := w.Header().Get("X-RateLimit-Limit"); got != "1" {
+				t.Errorf("X-RateLimit-Limit = %q, want %q", got, "1")
+			}
+			if !strings.Contains(w.Body.String(), `"code":"`+codeRateLimited+`"`) {
+				t.Errorf("body = %s, want code %s", w.Body, codeRateLimited)
+			}
+		})
+	}
+}
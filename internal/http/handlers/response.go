@@ -1,3 +1,131 @@
 package handlers
 
-// Placeholder for future shared response helpers.
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"service-timetable/internal/domain"
+)
+
+type resolvedDayResponse struct {
+	ClassID string         `json:"class_id"`
+	Date    string         `json:"date"`
+	Version int64          `json:"version"`
+	Locked  bool           `json:"locked"`
+	Slots   []slotResponse `json:"slots"`
+	Locks   []lockResponse `json:"locks"`
+}
+
+type slotResponse struct {
+	SlotIndex  int     `json:"slot_index"`
+	CourseCode string  `json:"course_code"`
+	StartTime  string  `json:"start_time"`
+	EndTime    string  `json:"end_time"`
+	Venue      string  `json:"venue"`
+	FacultyID  *string `json:"faculty_id"`
+	Status     string  `json:"status"`
+	Version    int64   `json:"version"`
+	Locked     bool    `json:"locked"`
+}
+
+type lockResponse struct {
+	SlotIndex *int   `json:"slot_index"`
+	LockedBy  string `json:"locked_by"`
+	LockedAt  string `json:"locked_at"`
+	Reason    string `json:"reason"`
+}
+
+func lockToResponse(lock domain.DayLock) lockResponse {
+	response := lockResponse{
+		LockedBy: lock.LockedBy.String(),
+		LockedAt: lock.LockedAt.UTC().Format(time.RFC3339),
+		Reason:   lock.Reason,
+	}
+	if lock.SlotIndex != 0 {
+		slotIndex := lock.SlotIndex
+		response.SlotIndex = &slotIndex
+	}
+	return response
+}
+
+func resolvedDayToResponse(day domain.ResolvedDay) resolvedDayResponse {
+	locked := func(slotIndex int) bool {
+		for _, lock := range day.Locks {
+			if lock.Covers(slotIndex) {
+				return true
+			}
+		}
+		return false
+	}
+
+	slots := make([]slotResponse, 0, len(day.Slots))
+	for _, slot := range day.Slots {
+		var facultyID *string
+		if slot.FacultyID != nil {
+			id := slot.FacultyID.String()
+			facultyID = &id
+		}
+		slots = append(slots, slotResponse{
+			SlotIndex:  slot.SlotIndex,
+			CourseCode: slot.CourseCode,
+			StartTime:  formatClock(slot.StartTime),
+			EndTime:    formatClock(slot.EndTime),
+			Venue:      slot.Venue,
+			FacultyID:  facultyID,
+			Status:     slot.Status,
+			Version:    slot.Version,
+			Locked:     locked(slot.SlotIndex),
+		})
+	}
+	locks := make([]lockResponse, 0, len(day.Locks))
+	for _, lock := range day.Locks {
+		locks = append(locks, lockToResponse(lock))
+	}
+	return resolvedDayResponse{
+		ClassID: day.ClassID.String(),
+		Date:    day.Date.Format("2006-01-02"),
+		Version: day.Version,
+		Locked:  locked(0),
+		Slots:   slots,
+		Locks:   locks,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func formatClock(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("15:04")
+}
+
+func formatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseIfMatch returns the day version a write is conditioned on, or nil when
+// the request is unconditional. Only a single entity tag is accepted.
+func parseIfMatch(value string) (*int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return nil, errors.New("malformed If-Match header")
+	}
+	version, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version < 0 {
+		return nil, errors.New("malformed If-Match header")
+	}
+	return &version, nil
+}
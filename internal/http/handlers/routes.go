@@ -0,0 +1,48 @@
+package handlers
+
+import "net/http"
+
+type Route struct {
+	Method  string
+	Path    string
+	Handler http.HandlerFunc
+}
+
+// RegisterRoutes mounts routes on mux, one dispatcher per path, so that
+// unsupported methods get the JSON error envelope instead of the mux default.
+func RegisterRoutes(mux *http.ServeMux, routes []Route) {
+	byPath := make(map[string][]Route)
+	var paths []string
+	for _, route := range routes {
+		if _, ok := byPath[route.Path]; !ok {
+			paths = append(paths, route.Path)
+		}
+		byPath[route.Path] = append(byPath[route.Path], route)
+	}
+
+	for _, path := range paths {
+		mux.HandleFunc(path, withIdempotencyKey(dispatchByMethod(byPath[path])))
+	}
+}
+
+func dispatchByMethod(routes []Route) http.HandlerFunc {
+	allowed := make([]string, 0, len(routes))
+	for _, route := range routes {
+		allowed = append(allowed, route.Method)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, route := range routes {
+			if route.Method == r.Method {
+				route.Handler(w, r)
+				return
+			}
+		}
+		writeMethodNotAllowed(w, allowed...)
+	}
+}
+
+// WriteServiceError writes err using the service error envelope. It lets
+// middleware outside this package report failures the same way handlers do.
+func WriteServiceError(w http.ResponseWriter, err error) {
+	writeServiceError(w, err)
+}
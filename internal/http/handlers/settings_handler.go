@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/service"
+)
+
+type scheduleResponse struct {
+	Name               string            `json:"name"`
+	DaysAhead          int               `json:"days_ahead"`
+	AnnounceTime       string            `json:"announce_time"`
+	Template           string            `json:"template"`
+	LocalizedTemplates map[string]string `json:"localized_templates"`
+	LastAnnouncedDate  *string           `json:"last_announced_date"`
+}
+
+type settingsResponse struct {
+	ClassID                  string             `json:"class_id"`
+	MatrixRoomID             string             `json:"matrix_room_id"`
+	Locale                   string             `json:"locale"`
+	UpdateTemplate           string             `json:"update_template"`
+	LocalizedUpdateTemplates map[string]string  `json:"localized_update_templates"`
+	ReminderMinutes          *int               `json:"reminder_minutes"`
+	ReminderChangedOnly      bool               `json:"reminder_changed_only"`
+	Schedules                []scheduleResponse `json:"schedules"`
+}
+
+func settingsToResponse(settings domain.AnnouncementSettings) settingsResponse {
+	response := settingsResponse{
+		ClassID:                  settings.ClassID.String(),
+		MatrixRoomID:             settings.MatrixRoomID,
+		Locale:                   settings.Locale,
+		UpdateTemplate:           settings.UpdateTemplate,
+		LocalizedUpdateTemplates: settings.LocalizedUpdateTemplates,
+		ReminderMinutes:          settings.ReminderMinutes,
+		ReminderChangedOnly:      settings.ReminderChangedOnly,
+		Schedules:                make([]scheduleResponse, 0, len(settings.Schedules)),
+	}
+	for _, schedule := range settings.Schedules {
+		item := scheduleResponse{
+			Name:               schedule.Name,
+			DaysAhead:          schedule.DaysAhead,
+			AnnounceTime:       formatClock(schedule.AnnounceTime),
+			Template:           schedule.Template,
+			LocalizedTemplates: schedule.LocalizedTemplates,
+		}
+		if schedule.LastAnnouncedDate != nil {
+			date := schedule.LastAnnouncedDate.Format("2006-01-02")
+			item.LastAnnouncedDate = &date
+		}
+		response.Schedules = append(response.Schedules, item)
+	}
+	return response
+}
+
+func (h *AdminHandler) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	classID, err := uuid.Parse(r.URL.Query().Get("class_id"))
+	if err != nil {
+		writeServiceError(w, invalidUUID("class_id"))
+		return
+	}
+
+	settings, err := h.service.GetAnnouncementSettings(r.Context(), requesterID, classID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, settingsToResponse(settings))
+}
+
+type scheduleRequest struct {
+	Name               string            `json:"name"`
+	DaysAhead          int               `json:"days_ahead"`
+	AnnounceTime       string            `json:"announce_time"`
+	Template           string            `json:"template"`
+	LocalizedTemplates map[string]string `json:"localized_templates"`
+}
+
+type updateSettingsRequest struct {
+	ClassID                  string            `json:"class_id"`
+	MatrixRoomID             string            `json:"matrix_room_id"`
+	Locale                   string            `json:"locale"`
+	UpdateTemplate           string            `json:"update_template"`
+	LocalizedUpdateTemplates map[string]string `json:"localized_update_templates"`
+	ReminderMinutes          *int              `json:"reminder_minutes"`
+	ReminderChangedOnly      bool              `json:"reminder_changed_only"`
+	Schedules                []scheduleRequest `json:"schedules"`
+}
+
+func (h *AdminHandler) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var req updateSettingsRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	classID, err := uuid.Parse(req.ClassID)
+	if err != nil {
+		invalid.Add("class_id", "must be a UUID")
+	}
+	schedules := make([]domain.AnnouncementSchedule, 0, len(req.Schedules))
+	for i, schedule := range req.Schedules {
+		announceTime, err := parseTime(schedule.AnnounceTime)
+		if err != nil {
+			invalid.Add(fmt.Sprintf("schedules[%d].announce_time", i), timeFormatMessage)
+		}
+		schedules = append(schedules, domain.AnnouncementSchedule{
+			ClassID:            classID,
+			Name:               schedule.Name,
+			DaysAhead:          schedule.DaysAhead,
+			AnnounceTime:       announceTime,
+			Template:           schedule.Template,
+			LocalizedTemplates: schedule.LocalizedTemplates,
+		})
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	err = h.service.UpdateAnnouncementSettings(
+		r.Context(),
+		requesterID,
+		classID,
+		req.MatrixRoomID,
+		req.Locale,
+		req.UpdateTemplate,
+		req.LocalizedUpdateTemplates,
+		req.ReminderMinutes,
+		req.ReminderChangedOnly,
+		schedules,
+	)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type previewTemplateRequest struct {
+	ClassID  string `json:"class_id"`
+	Date     string `json:"date"`
+	Locale   string `json:"locale"`
+	Template string `json:"template"`
+}
+
+type previewTemplateResponse struct {
+	Date    string `json:"date"`
+	Message string `json:"message"`
+}
+
+func (h *AdminHandler) handlePreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	requesterID, err := requesterFromContext(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	var req previewTemplateRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	var invalid service.ValidationError
+	classID, err := uuid.Parse(req.ClassID)
+	if err != nil {
+		invalid.Add("class_id", "must be a UUID")
+	}
+	date, err := parseDate(req.Date)
+	if err != nil {
+		invalid.Add("date", dateFormatMessage)
+	}
+	if err := invalid.Err(); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	message, err := h.service.PreviewTemplate(r.Context(), requesterID, classID, date, req.Locale, req.Template)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, previewTemplateResponse{Date: req.Date, Message: message})
+}
@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/google/uuid"
+
+	"service-timetable/internal/service"
+)
+
+const SpecPath = "/openapi.yaml"
+
+//go:embed openapi.yaml
+var spec []byte
+
+type Route struct {
+	Method string
+	Path   string
+}
+
+type Document struct {
+	doc    *openapi3.T
+	router routers.Router
+}
+
+func init() {
+	openapi3.DefineStringFormatCallback("uuid", func(value string) error {
+		_, err := uuid.Parse(value)
+		return err
+	})
+}
+
+func Load() (*Document, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("load openapi spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("validate openapi spec: %w", err)
+	}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("build openapi router: %w", err)
+	}
+	return &Document{doc: doc, router: router}, nil
+}
+
+func (d *Document) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(spec)
+	})
+}
+
+// CheckRoutes reports drift between the served routes and the document: every
+// route must be documented and every documented operation must be served.
+func (d *Document) CheckRoutes(served []Route) error {
+	documented := make(map[Route]bool)
+	for path, item := range d.doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented[Route{Method: method, Path: path}] = true
+		}
+	}
+
+	var problems []string
+	for _, route := range served {
+		if !documented[route] {
+			problems = append(problems, "undocumented route "+route.Method+" "+route.Path)
+		}
+		delete(documented, route)
+	}
+	for route := range documented {
+		problems = append(problems, "documented route not served "+route.Method+" "+route.Path)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return errors.New("openapi drift: " + strings.Join(problems, "; "))
+}
+
+// ValidateRequests checks parameters and bodies of documented operations
+// against the document before they reach next. Failures are passed to onError
+// as a *service.ValidationError; undocumented requests pass through untouched.
+func (d *Document) ValidateRequests(next http.Handler, onError func(http.ResponseWriter, error)) http.Handler {
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := d.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		})
+		if err != nil {
+			onError(w, toValidationError(err))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func toValidationError(err error) error {
+	// A body over the size limit is not a validation failure and keeps its
+	// own status.
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return tooLarge
+	}
+	var invalid service.ValidationError
+	collectFieldErrors(&invalid, "", err)
+	if len(invalid.Fields) == 0 {
+		invalid.Add("body", err.Error())
+	}
+	return &invalid
+}
+
+func collectFieldErrors(invalid *service.ValidationError, field string, err error) {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, inner := range e {
+			collectFieldErrors(invalid, field, inner)
+		}
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			field = e.Parameter.Name
+		case e.RequestBody != nil:
+			field = "body"
+		}
+		switch {
+		case e.Err == nil:
+			invalid.Add(field, e.Reason)
+		case errors.Is(e.Err, openapi3filter.ErrInvalidRequired):
+			invalid.Add(field, "is required")
+		default:
+			collectFieldErrors(invalid, field, e.Err)
+		}
+	case *openapi3.SchemaError:
+		invalid.Add(schemaErrorField(field, e))
+	default:
+		invalid.Add(field, err.Error())
+	}
+}
+
+// schemaErrorField names the body property a schema error refers to. Errors
+// about missing or unknown properties are reported against the property
+// itself rather than its parent object.
+func schemaErrorField(field string, err *openapi3.SchemaError) (string, string) {
+	path := err.JSONPointer()
+	message := err.Reason
+	if rest, ok := strings.CutPrefix(message, `property "`); ok {
+		if name, reason, ok := strings.Cut(rest, `" `); ok {
+			if len(path) == 0 || path[len(path)-1] != name {
+				path = append(path, name)
+			}
+			message = reason
+		}
+	}
+	if len(path) > 0 && field == "body" {
+		field = strings.Join(path, ".")
+	}
+	return field, message
+}
@@ -3,20 +3,61 @@ package http
 import (
 	"net/http"
 
+	"service-timetable/internal/auth"
 	"service-timetable/internal/http/handlers"
+	"service-timetable/internal/http/openapi"
+	"service-timetable/internal/metrics"
+	"service-timetable/internal/service"
 )
 
+const metricsPath = "/metrics"
+
 type Router struct {
-	mux *http.ServeMux
+	handler http.Handler
 }
 
-func NewRouter(adminHandler *handlers.AdminHandler) *Router {
+func NewRouter(adminHandler *handlers.AdminHandler, healthHandler *handlers.HealthHandler, authenticator *auth.Authenticator, limiter *service.RateLimiter) (*Router, error) {
+	spec, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	routes := adminHandler.Routes()
+	publicRoutes := healthHandler.Routes()
+	served := servedRoutes(append(routes, publicRoutes...))
+	if err := spec.CheckRoutes(served); err != nil {
+		return nil, err
+	}
+
+	adminMux := http.NewServeMux()
+	handlers.RegisterRoutes(adminMux, routes)
+	admin := handlers.WithRateLimit(limiter, spec.ValidateRequests(adminMux, handlers.WriteServiceError))
+
 	mux := http.NewServeMux()
-	adminHandler.Register(mux)
+	mux.Handle(openapi.SpecPath, spec.Handler())
+	mux.Handle(metricsPath, metrics.Handler())
+	handlers.RegisterRoutes(mux, publicRoutes)
+	mux.Handle("/admin/", authenticator.Middleware(admin, handlers.WriteServiceError))
+
+	paths := make([]string, 0, len(served))
+	for _, route := range served {
+		paths = append(paths, route.Path)
+	}
+	return &Router{handler: handlers.WithObservability(paths, handlers.WithBodyLimit(mux))}, nil
+}
 
-	return &Router{mux: mux}
+// servedRoutes lists routes together with the ones the router serves itself.
+func servedRoutes(routes []handlers.Route) []openapi.Route {
+	served := []openapi.Route{
+		{Method: http.MethodGet, Path: openapi.SpecPath},
+		{Method: http.MethodGet, Path: metricsPath},
+	}
+	for _, route := range routes {
+		served = append(served, openapi.Route{Method: route.Method, Path: route.Path})
+	}
+	return served
 }
 
 func (r *Router) Handler() http.Handler {
-	return r.mux
+	return r.handler
 }
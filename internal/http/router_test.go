@@ -0,0 +1,242 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"service-timetable/internal/auth"
+	"service-timetable/internal/http/handlers"
+	"service-timetable/internal/http/openapi"
+	"service-timetable/internal/service"
+)
+
+const testUserID = "7b0f7c4e-2f65-4e8a-9a44-8c1c7f0e2a11"
+
+func newTestRouter(t *testing.T) *Router {
+	t.Helper()
+	router, err := NewRouter(
+		handlers.NewAdminHandler(nil, nil),
+		handlers.NewHealthHandler(nil),
+		auth.NewTrustedGatewayAuthenticator(),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return router
+}
+
+func testRoutes() []handlers.Route {
+	routes := handlers.NewAdminHandler(nil, nil).Routes()
+	return append(routes, handlers.NewHealthHandler(nil).Routes()...)
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	if err := spec.CheckRoutes(servedRoutes(testRoutes())); err != nil {
+		t.Fatalf("CheckRoutes: %v", err)
+	}
+
+	newTestRouter(t)
+}
+
+func TestCheckRoutesReportsDrift(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	routes := testRoutes()
+	dropped := routes[0]
+	routes = append(slices.Clone(routes[1:]), handlers.Route{Method: http.MethodPatch, Path: "/admin/timetable/today"})
+
+	err = spec.CheckRoutes(servedRoutes(routes))
+	if err == nil {
+		t.Fatal("CheckRoutes: expected drift to be reported")
+	}
+	for _, want := range []string{
+		"undocumented route PATCH /admin/timetable/today",
+		"documented route not served " + dropped.Method + " " + dropped.Path,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("CheckRoutes error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+		field  string
+	}{
+		{
+			name:   "unknown field",
+			method: http.MethodPost,
+			target: "/admin/timetable/today",
+			body:   `{"class_id": "` + testUserID + `", "slot_index": 1, "status": "cancelled", "room": "E-205"}`,
+			status: http.StatusBadRequest,
+			code:   "invalid_input",
+			field:  "room",
+		},
+		{
+			name:   "wrong type",
+			method: http.MethodPost,
+			target: "/admin/timetable/today",
+			body:   `{"class_id": "` + testUserID + `", "slot_index": "one", "status": "cancelled"}`,
+			status: http.StatusBadRequest,
+			code:   "invalid_input",
+			field:  "slot_index",
+		},
+		{
+			name:   "missing required field",
+			method: http.MethodPost,
+			target: "/admin/timetable/today",
+			body:   `{"class_id": "` + testUserID + `", "slot_index": 1}`,
+			status: http.StatusBadRequest,
+			code:   "invalid_input",
+			field:  "status",
+		},
+		{
+			name:   "malformed uuid",
+			method: http.MethodPost,
+			target: "/admin/timetable/today",
+			body:   `{"class_id": "not-a-uuid", "slot_index": 1, "status": "cancelled"}`,
+			status: http.StatusBadRequest,
+			code:   "invalid_input",
+			field:  "class_id",
+		},
+		{
+			name:   "unknown status",
+			method: http.MethodPost,
+			target: "/admin/timetable/today",
+			body:   `{"class_id": "` + testUserID + `", "slot_index": 1, "status": "moved"}`,
+			status: http.StatusBadRequest,
+			code:   "invalid_input",
+			field:  "status",
+		},
+		{
+			name:   "malformed json",
+			method: http.MethodPost,
+			target: "/admin/timetable/today",
+			body:   `{"class_id": `,
+			status: http.StatusBadRequest,
+			code:   "invalid_input",
+		},
+		{
+			name:   "malformed query parameter",
+			method: http.MethodGet,
+			target: "/admin/timetable/today?class_id=nope",
+			status: http.StatusBadRequest,
+			code:   "invalid_input",
+			field:  "class_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-User-ID", testUserID)
+			rec := httptest.NewRecorder()
+
+			router.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.status, rec.Body)
+			}
+			var response struct {
+				Error struct {
+					Code    string `json:"code"`
+					Details []struct {
+						Field string `json:"field"`
+					} `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode error envelope: %v", err)
+			}
+			if response.Error.Code != tt.code {
+				t.Errorf("code = %q, want %q", response.Error.Code, tt.code)
+			}
+			if tt.field == "" {
+				return
+			}
+			var fields []string
+			for _, detail := range response.Error.Details {
+				fields = append(fields, detail.Field)
+			}
+			if !slices.Contains(fields, tt.field) {
+				t.Errorf("details fields = %v, want %q among them", fields, tt.field)
+			}
+		})
+	}
+}
+
+func TestUnauthenticatedRequestsAreRejected(t *testing.T) {
+	router := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/timetable/today?class_id="+testUserID, nil)
+	rec := httptest.NewRecorder()
+	router.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestOversizedBodiesAreRejected(t *testing.T) {
+	limited, err := NewRouter(
+		handlers.NewAdminHandler(nil, nil),
+		handlers.NewHealthHandler(nil),
+		auth.NewTrustedGatewayAuthenticator(),
+		service.NewRateLimiter(service.RateLimit{PerMinute: 60, Burst: 10}, service.RateLimit{PerMinute: 60, Burst: 10}),
+	)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	body := `{"class_id": "` + testUserID + `", "slot_index": 1, "status": "cancelled", "venue": "` + strings.Repeat("x", 2<<20) + `"}`
+
+	tests := []struct {
+		name           string
+		router         *Router
+		idempotencyKey string
+	}{
+		{name: "validation", router: newTestRouter(t)},
+		{name: "idempotency key", router: newTestRouter(t), idempotencyKey: "retry-1"},
+		{name: "rate limit", router: limited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/timetable/today", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-User-ID", testUserID)
+			if tt.idempotencyKey != "" {
+				req.Header.Set("Idempotency-Key", tt.idempotencyKey)
+			}
+			rec := httptest.NewRecorder()
+
+			tt.router.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusRequestEntityTooLarge, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), `"request_too_large"`) {
+				t.Errorf("body = %s, want code request_too_large", rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Package leader elects one replica to run scheduled jobs, using a lease
+// row in Postgres that the leader keeps renewing.
+package leader
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/metrics"
+	"service-timetable/internal/repository"
+)
+
+// Status is the last known state of the lease. Leader is empty while no
+// replica holds it.
+type Status struct {
+	Self      string
+	Leader    string
+	IsLeader  bool
+	ExpiresAt time.Time
+}
+
+// Elector campaigns for a lease and renews it every ttl/3. Leadership is
+// given up locally as soon as the lease could have expired without a
+// successful renewal, so a replica cut off from the database stops acting
+// as leader before another one may take over.
+type Elector struct {
+	leases repository.LeaderLeaseRepository
+	name   string
+	self   string
+	ttl    time.Duration
+
+	mu         sync.Mutex
+	lease      domain.LeaderLease
+	validUntil time.Time
+}
+
+func NewElector(leases repository.LeaderLeaseRepository, name string, self string, ttl time.Duration) *Elector {
+	return &Elector{leases: leases, name: name, self: self, ttl: ttl}
+}
+
+// DefaultIdentity names this replica by host name, with a random suffix so
+// that processes sharing a host do not share leadership.
+func DefaultIdentity() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "replica"
+	}
+	var suffix [4]byte
+	_, _ = rand.Read(suffix[:])
+	return host + "-" + hex.EncodeToString(suffix[:])
+}
+
+// Run campaigns until ctx is done, then releases the lease if held.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		e.campaign(ctx)
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return time.Now().Before(e.validUntil)
+}
+
+func (e *Elector) Status() Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return Status{
+		Self:      e.self,
+		Leader:    e.lease.Holder,
+		IsLeader:  time.Now().Before(e.validUntil),
+		ExpiresAt: e.lease.ExpiresAt,
+	}
+}
+
+func (e *Elector) campaign(ctx context.Context) {
+	started := time.Now()
+	attemptCtx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+
+	lease, acquired, err := e.leases.TryAcquire(attemptCtx, e.name, e.self, e.ttl)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+
+	e.mu.Lock()
+	wasLeader := time.Now().Before(e.validUntil)
+	switch {
+	case err != nil:
+		// Keep the current state; validUntil runs out on its own.
+	case acquired:
+		e.lease = lease
+		e.validUntil = started.Add(e.ttl)
+	default:
+		e.lease = lease
+		e.validUntil = time.Time{}
+	}
+	isLeader := time.Now().Before(e.validUntil)
+	leaderName := e.lease.Holder
+	e.mu.Unlock()
+
+	metrics.SetLeader(isLeader)
+	if err != nil {
+		slog.WarnContext(ctx, "leader lease renewal failed", "lease", e.name, "error", err)
+	}
+	switch {
+	case isLeader && !wasLeader:
+		slog.InfoContext(ctx, "became leader", "lease", e.name, "self", e.self)
+	case !isLeader && wasLeader:
+		slog.WarnContext(ctx, "lost leadership", "lease", e.name, "self", e.self, "leader", leaderName)
+	}
+}
+
+func (e *Elector) release() {
+	e.mu.Lock()
+	held := time.Now().Before(e.validUntil)
+	e.validUntil = time.Time{}
+	e.mu.Unlock()
+	metrics.SetLeader(false)
+	if !held {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.leases.Release(ctx, e.name, e.self); err != nil {
+		slog.WarnContext(ctx, "leader lease release failed", "lease", e.name, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "released leadership", "lease", e.name, "self", e.self)
+}
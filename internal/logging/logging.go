@@ -0,0 +1,68 @@
+// Package logging configures structured JSON logging. Attributes stored in
+// a context with With, such as the request ID, are added to every record
+// logged with that context, so code deeper in the call stack only needs to
+// use the slog *Context functions.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// New returns a JSON logger writing records at level and above. level is
+// one of debug, info, warn and error.
+func New(w io.Writer, level string) (*slog.Logger, error) {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: parsed})
+	return slog.New(contextHandler{Handler: handler}), nil
+}
+
+type attrsContextKey struct{}
+
+type requestIDContextKey struct{}
+
+// With returns a context whose log records carry attrs in addition to those
+// already in ctx.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsContextKey{}).([]slog.Attr)
+	combined := make([]slog.Attr, 0, len(existing)+len(attrs))
+	combined = append(combined, existing...)
+	combined = append(combined, attrs...)
+	return context.WithValue(ctx, attrsContextKey{}, combined)
+}
+
+// WithRequestID stores the request ID in ctx and adds it to log records.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDContextKey{}, requestID)
+	return With(ctx, slog.String("request_id", requestID))
+}
+
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDContextKey{}).(string)
+	return requestID, ok
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(attrsContextKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,338 @@
+// Package metrics holds the Prometheus metrics of the service. Metric names
+// are part of the service's interface and are documented in the README;
+// rename them only with a migration path for dashboards and alerts.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "timetable"
+
+const (
+	IdentityOK           = "ok"
+	IdentityNotFound     = "not_found"
+	IdentityUnauthorized = "unauthorized"
+	IdentityUnavailable  = "unavailable"
+	IdentityCircuitOpen  = "circuit_open"
+	IdentityError        = "error"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	overrideWrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "override_writes_total",
+		Help:      "Committed slot overrides by slot status.",
+	}, []string{"status"})
+
+	txDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tx_duration_seconds",
+		Help:      "Database transaction duration by outcome (commit or rollback).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	txRollbacks = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tx_rollbacks_total",
+		Help:      "Database transactions rolled back.",
+	})
+
+	announcementTickDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "announcement_tick_duration_seconds",
+		Help:      "Duration of announcement runs.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	announcementTickErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "announcement_tick_errors_total",
+		Help:      "Announcement runs that failed.",
+	})
+
+	classesAnnounced = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "announcement_classes_announced_total",
+		Help:      "Daily and preview announcements written to the outbox.",
+	})
+
+	identityDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "identity_request_duration_seconds",
+		Help:      "Latency of service-identity calls by outcome, per attempt.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	identityErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "identity_errors_total",
+		Help:      "Failed service-identity calls by reason.",
+	}, []string{"reason"})
+
+	jobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Finished scheduled job runs by job and outcome.",
+	}, []string{"job", "outcome"})
+
+	jobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Duration of scheduled job runs by job.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"job"})
+
+	jobSkips = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_skipped_overlaps_total",
+		Help:      "Scheduled job runs skipped because the previous run was still going.",
+	}, []string{"job"})
+
+	leader = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "leader",
+		Help:      "1 while this replica holds the scheduler leader lease, else 0.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		overrideWrites,
+		txDuration,
+		txRollbacks,
+		announcementTickDuration,
+		announcementTickErrors,
+		classesAnnounced,
+		identityDuration,
+		identityErrors,
+		jobRuns,
+		jobDuration,
+		jobSkips,
+		leader,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+func ObserveHTTPRequest(route, method string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(route, method, code).Inc()
+	httpDuration.WithLabelValues(route, method, code).Observe(elapsed.Seconds())
+}
+
+func OverrideWritten(status string) {
+	overrideWrites.WithLabelValues(status).Inc()
+}
+
+func ObserveTx(elapsed time.Duration, rolledBack bool) {
+	outcome := "commit"
+	if rolledBack {
+		outcome = "rollback"
+		txRollbacks.Inc()
+	}
+	txDuration.WithLabelValues(outcome).Observe(elapsed.Seconds())
+}
+
+func ObserveAnnouncementTick(elapsed time.Duration, announced int, failed bool) {
+	announcementTickDuration.Observe(elapsed.Seconds())
+	classesAnnounced.Add(float64(announced))
+	if failed {
+		announcementTickErrors.Inc()
+	}
+}
+
+// ObserveIdentityRequest records one call to service-identity. Outcomes
+// other than ok, not_found and unauthorized count as errors.
+func ObserveIdentityRequest(outcome string, elapsed time.Duration) {
+	identityDuration.WithLabelValues(outcome).Observe(elapsed.Seconds())
+	switch outcome {
+	case IdentityOK, IdentityNotFound, IdentityUnauthorized:
+	default:
+		identityErrors.WithLabelValues(outcome).Inc()
+	}
+}
+
+func ObserveJobRun(job, outcome string, elapsed time.Duration) {
+	jobRuns.WithLabelValues(job, outcome).Inc()
+	jobDuration.WithLabelValues(job).Observe(elapsed.Seconds())
+}
+
+func JobSkipped(job string) {
+	jobSkips.WithLabelValues(job).Inc()
+}
+
+func SetLeader(isLeader bool) {
+	if isLeader {
+		leader.Set(1)
+		return
+	}
+	leader.Set(0)
+}
+
+// IdentityRejected records a call the circuit breaker refused to make.
+func IdentityRejected() {
+	identityErrors.WithLabelValues(IdentityCircuitOpen).Inc()
+}
+
+// OutboxBacklogFunc reports the number of unpublished outbox events and the
+// creation time of the oldest one, which is zero when there are none.
+type OutboxBacklogFunc func(ctx context.Context) (int, time.Time, error)
+
+// RegisterOutboxBacklog exports the outbox backlog, read on every scrape.
+func RegisterOutboxBacklog(backlog OutboxBacklogFunc) {
+	registry.MustRegister(&outboxCollector{backlog: backlog})
+}
+
+var (
+	outboxBacklogDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "outbox_backlog_events"),
+		"Unpublished outbox events.",
+		nil, nil,
+	)
+	outboxAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "outbox_oldest_unpublished_age_seconds"),
+		"Age of the oldest unpublished outbox event, 0 when there is none.",
+		nil, nil,
+	)
+	outboxScrapeErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "outbox_backlog_scrape_error"),
+		"1 when the outbox backlog could not be read during this scrape.",
+		nil, nil,
+	)
+)
+
+type outboxCollector struct {
+	backlog OutboxBacklogFunc
+}
+
+func (c *outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- outboxBacklogDesc
+	ch <- outboxAgeDesc
+	ch <- outboxScrapeErrorDesc
+}
+
+func (c *outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, oldest, err := c.backlog(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(outboxScrapeErrorDesc, prometheus.GaugeValue, 1)
+		return
+	}
+	var age float64
+	if !oldest.IsZero() {
+		age = time.Since(oldest).Seconds()
+	}
+	ch <- prometheus.MustNewConstMetric(outboxBacklogDesc, prometheus.GaugeValue, float64(count))
+	ch <- prometheus.MustNewConstMetric(outboxAgeDesc, prometheus.GaugeValue, age)
+	ch <- prometheus.MustNewConstMetric(outboxScrapeErrorDesc, prometheus.GaugeValue, 0)
+}
+
+// IdentityCacheStats are the counters of the identity cache since process
+// start and the state of its circuit breaker.
+type IdentityCacheStats struct {
+	Hits         uint64
+	NegativeHits uint64
+	Misses       uint64
+	Entries      int
+	BreakerState string
+}
+
+// breakerStates are the states of the identity circuit breaker, as reported
+// by service.CircuitBreaker.
+var breakerStates = []string{"closed", "open", "half-open"}
+
+// RegisterIdentityCache exports the identity cache and breaker statistics,
+// read on every scrape.
+func RegisterIdentityCache(stats func() IdentityCacheStats) {
+	registry.MustRegister(&identityCacheCollector{stats: stats})
+}
+
+var (
+	identityCacheHitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "identity_cache_hits_total"),
+		"Identity lookups answered from the cache.",
+		nil, nil,
+	)
+	identityCacheNegativeHitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "identity_cache_negative_hits_total"),
+		"Identity lookups answered from the cache of unknown users.",
+		nil, nil,
+	)
+	identityCacheMissesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "identity_cache_misses_total"),
+		"Identity lookups passed on to service-identity.",
+		nil, nil,
+	)
+	identityCacheEntriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "identity_cache_entries"),
+		"Users held in the identity cache.",
+		nil, nil,
+	)
+	identityBreakerStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "identity_breaker_state"),
+		"1 for the current state of the service-identity circuit breaker, else 0.",
+		[]string{"state"}, nil,
+	)
+)
+
+type identityCacheCollector struct {
+	stats func() IdentityCacheStats
+}
+
+func (c *identityCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- identityCacheHitsDesc
+	ch <- identityCacheNegativeHitsDesc
+	ch <- identityCacheMissesDesc
+	ch <- identityCacheEntriesDesc
+	ch <- identityBreakerStateDesc
+}
+
+func (c *identityCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(identityCacheHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(identityCacheNegativeHitsDesc, prometheus.CounterValue, float64(stats.NegativeHits))
+	ch <- prometheus.MustNewConstMetric(identityCacheMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(identityCacheEntriesDesc, prometheus.GaugeValue, float64(stats.Entries))
+	if stats.BreakerState == "" {
+		return
+	}
+	for _, state := range breakerStates {
+		var value float64
+		if state == stats.BreakerState {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(identityBreakerStateDesc, prometheus.GaugeValue, value, state)
+	}
+}
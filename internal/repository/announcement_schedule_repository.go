@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+)
+
+type AnnouncementScheduleRepository interface {
+	ListAll(ctx context.Context) ([]domain.AnnouncementSchedule, error)
+	ListByClassID(ctx context.Context, classID uuid.UUID) ([]domain.AnnouncementSchedule, error)
+	Replace(ctx context.Context, classID uuid.UUID, schedules []domain.AnnouncementSchedule) error
+	MarkAnnounced(ctx context.Context, classID uuid.UUID, name string, date time.Time) (bool, error)
+}
+
+type AnnouncementSchedulePostgresRepository struct {
+	execer Execer
+}
+
+func NewAnnouncementSchedulePostgresRepository(execer Execer) *AnnouncementSchedulePostgresRepository {
+	return &AnnouncementSchedulePostgresRepository{execer: execer}
+}
+
+func (r *AnnouncementSchedulePostgresRepository) ListAll(ctx context.Context) ([]domain.AnnouncementSchedule, error) {
+	const query = `
+SELECT class_id, name, days_ahead, announce_time, template, localized_templates, last_announced_date
+FROM timetable.announcement_schedules
+ORDER BY class_id, announce_time, name
+`
+
+	return r.list(ctx, query)
+}
+
+func (r *AnnouncementSchedulePostgresRepository) ListByClassID(ctx context.Context, classID uuid.UUID) ([]domain.AnnouncementSchedule, error) {
+	const query = `
+SELECT class_id, name, days_ahead, announce_time, template, localized_templates, last_announced_date
+FROM timetable.announcement_schedules
+WHERE class_id = $1
+ORDER BY announce_time, name
+`
+
+	return r.list(ctx, query, classID)
+}
+
+func (r *AnnouncementSchedulePostgresRepository) list(ctx context.Context, query string, args ...any) ([]domain.AnnouncementSchedule, error) {
+	rows, err := r.execer.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []domain.AnnouncementSchedule
+	for rows.Next() {
+		var schedule domain.AnnouncementSchedule
+		var localized []byte
+		var lastDate sql.NullTime
+		if err := rows.Scan(
+			&schedule.ClassID,
+			&schedule.Name,
+			&schedule.DaysAhead,
+			&schedule.AnnounceTime,
+			&schedule.Template,
+			&localized,
+			&lastDate,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(localized, &schedule.LocalizedTemplates); err != nil {
+			return nil, err
+		}
+		if lastDate.Valid {
+			schedule.LastAnnouncedDate = &lastDate.Time
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// Replace makes schedules the class's only schedules. Schedules kept by name
+// keep their last_announced_date, so editing one does not announce again.
+func (r *AnnouncementSchedulePostgresRepository) Replace(ctx context.Context, classID uuid.UUID, schedules []domain.AnnouncementSchedule) error {
+	names := make([]string, 0, len(schedules))
+	for _, schedule := range schedules {
+		names = append(names, schedule.Name)
+	}
+
+	const remove = `
+DELETE FROM timetable.announcement_schedules
+WHERE class_id = $1 AND NOT (name = ANY($2))
+`
+	if _, err := r.execer.ExecContext(ctx, remove, classID, names); err != nil {
+		return err
+	}
+
+	const upsert = `
+INSERT INTO timetable.announcement_schedules (class_id, name, days_ahead, announce_time, template, localized_templates)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (class_id, name)
+DO UPDATE SET
+	days_ahead = EXCLUDED.days_ahead,
+	announce_time = EXCLUDED.announce_time,
+	template = EXCLUDED.template,
+	localized_templates = EXCLUDED.localized_templates
+`
+	for _, schedule := range schedules {
+		localized, err := marshalLocalizedTemplates(schedule.LocalizedTemplates)
+		if err != nil {
+			return err
+		}
+		if _, err := r.execer.ExecContext(
+			ctx,
+			upsert,
+			classID,
+			schedule.Name,
+			schedule.DaysAhead,
+			schedule.AnnounceTime,
+			schedule.Template,
+			localized,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarkAnnounced records that the schedule announced date. It reports false
+// when the schedule already announced date or a later one.
+func (r *AnnouncementSchedulePostgresRepository) MarkAnnounced(ctx context.Context, classID uuid.UUID, name string, date time.Time) (bool, error) {
+	const query = `
+UPDATE timetable.announcement_schedules
+SET last_announced_date = $3
+WHERE class_id = $1
+  AND name = $2
+  AND (last_announced_date IS NULL OR last_announced_date < $3)
+`
+
+	result, err := r.execer.ExecContext(ctx, query, classID, name, date)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -3,17 +3,19 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"time"
+	"encoding/json"
 
 	"github.com/google/uuid"
 
 	"service-timetable/internal/domain"
 )
 
+// AnnouncementSettingsRepository stores the per-class settings without their
+// schedules, which live in AnnouncementScheduleRepository.
 type AnnouncementSettingsRepository interface {
 	ListAll(ctx context.Context) ([]domain.AnnouncementSettings, error)
 	GetByClassID(ctx context.Context, classID uuid.UUID) (domain.AnnouncementSettings, error)
-	MarkAnnounced(ctx context.Context, classID uuid.UUID, date time.Time) (bool, error)
+	Upsert(ctx context.Context, settings domain.AnnouncementSettings) error
 }
 
 type AnnouncementSettingsPostgresRepository struct {
@@ -26,7 +28,7 @@ func NewAnnouncementSettingsPostgresRepository(execer Execer) *AnnouncementSetti
 
 func (r *AnnouncementSettingsPostgresRepository) ListAll(ctx context.Context) ([]domain.AnnouncementSettings, error) {
 	const query = `
-SELECT class_id, matrix_room_id, daily_announce_time, daily_template, update_template, last_announced_date
+SELECT class_id, matrix_room_id, locale, update_template, localized_update_templates, reminder_minutes, reminder_changed_only
 FROM timetable.announcement_settings
 ORDER BY class_id
 `
@@ -39,21 +41,10 @@ ORDER BY class_id
 
 	var settings []domain.AnnouncementSettings
 	for rows.Next() {
-		var entry domain.AnnouncementSettings
-		var lastDate sql.NullTime
-		if err := rows.Scan(
-			&entry.ClassID,
-			&entry.MatrixRoomID,
-			&entry.DailyAnnounceTime,
-			&entry.DailyTemplate,
-			&entry.UpdateTemplate,
-			&lastDate,
-		); err != nil {
+		entry, err := scanAnnouncementSettings(rows)
+		if err != nil {
 			return nil, err
 		}
-		if lastDate.Valid {
-			entry.LastAnnouncedDate = &lastDate.Time
-		}
 		settings = append(settings, entry)
 	}
 	if err := rows.Err(); err != nil {
@@ -65,48 +56,87 @@ ORDER BY class_id
 
 func (r *AnnouncementSettingsPostgresRepository) GetByClassID(ctx context.Context, classID uuid.UUID) (domain.AnnouncementSettings, error) {
 	const query = `
-SELECT class_id, matrix_room_id, daily_announce_time, daily_template, update_template, last_announced_date
+SELECT class_id, matrix_room_id, locale, update_template, localized_update_templates, reminder_minutes, reminder_changed_only
 FROM timetable.announcement_settings
 WHERE class_id = $1
 `
 
+	return scanAnnouncementSettings(r.execer.QueryRowContext(ctx, query, classID))
+}
+
+func scanAnnouncementSettings(row rowScanner) (domain.AnnouncementSettings, error) {
 	var entry domain.AnnouncementSettings
-	var lastDate sql.NullTime
-	if err := r.execer.QueryRowContext(ctx, query, classID).Scan(
+	var reminderMinutes sql.NullInt32
+	var localized []byte
+	if err := row.Scan(
 		&entry.ClassID,
 		&entry.MatrixRoomID,
-		&entry.DailyAnnounceTime,
-		&entry.DailyTemplate,
+		&entry.Locale,
 		&entry.UpdateTemplate,
-		&lastDate,
+		&localized,
+		&reminderMinutes,
+		&entry.ReminderChangedOnly,
 	); err != nil {
-		if err == sql.ErrNoRows {
-			return domain.AnnouncementSettings{}, err
-		}
 		return domain.AnnouncementSettings{}, err
 	}
-	if lastDate.Valid {
-		entry.LastAnnouncedDate = &lastDate.Time
+	if err := json.Unmarshal(localized, &entry.LocalizedUpdateTemplates); err != nil {
+		return domain.AnnouncementSettings{}, err
+	}
+	if reminderMinutes.Valid {
+		minutes := int(reminderMinutes.Int32)
+		entry.ReminderMinutes = &minutes
 	}
-
 	return entry, nil
 }
 
-func (r *AnnouncementSettingsPostgresRepository) MarkAnnounced(ctx context.Context, classID uuid.UUID, date time.Time) (bool, error) {
+func (r *AnnouncementSettingsPostgresRepository) Upsert(ctx context.Context, settings domain.AnnouncementSettings) error {
 	const query = `
-UPDATE timetable.announcement_settings
-SET last_announced_date = $2
-WHERE class_id = $1
-  AND (last_announced_date IS NULL OR last_announced_date < $2)
+INSERT INTO timetable.announcement_settings (
+	class_id,
+	matrix_room_id,
+	locale,
+	update_template,
+	localized_update_templates,
+	reminder_minutes,
+	reminder_changed_only
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (class_id)
+DO UPDATE SET
+	matrix_room_id = EXCLUDED.matrix_room_id,
+	locale = EXCLUDED.locale,
+	update_template = EXCLUDED.update_template,
+	localized_update_templates = EXCLUDED.localized_update_templates,
+	reminder_minutes = EXCLUDED.reminder_minutes,
+	reminder_changed_only = EXCLUDED.reminder_changed_only
 `
 
-	result, err := r.execer.ExecContext(ctx, query, classID, date)
+	localized, err := marshalLocalizedTemplates(settings.LocalizedUpdateTemplates)
 	if err != nil {
-		return false, err
+		return err
 	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return false, err
+	var reminderMinutes sql.NullInt32
+	if settings.ReminderMinutes != nil {
+		reminderMinutes = sql.NullInt32{Int32: int32(*settings.ReminderMinutes), Valid: true}
+	}
+	_, err = r.execer.ExecContext(
+		ctx,
+		query,
+		settings.ClassID,
+		settings.MatrixRoomID,
+		settings.Locale,
+		settings.UpdateTemplate,
+		localized,
+		reminderMinutes,
+		settings.ReminderChangedOnly,
+	)
+	return err
+}
+
+// marshalLocalizedTemplates encodes templates keyed by locale, storing none as
+// an empty object rather than null.
+func marshalLocalizedTemplates(templates map[string]string) ([]byte, error) {
+	if templates == nil {
+		templates = map[string]string{}
 	}
-	return rows > 0, nil
+	return json.Marshal(templates)
 }
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+)
+
+type APIKeyRepository interface {
+	Insert(ctx context.Context, key domain.APIKey, hash []byte) (domain.APIKey, error)
+	GetByHash(ctx context.Context, hash []byte) (domain.APIKey, error)
+	List(ctx context.Context) ([]domain.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) (bool, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID) error
+}
+
+type APIKeyPostgresRepository struct {
+	execer Execer
+}
+
+func NewAPIKeyPostgresRepository(execer Execer) *APIKeyPostgresRepository {
+	return &APIKeyPostgresRepository{execer: execer}
+}
+
+const apiKeyColumns = `id, name, key_prefix, scopes, class_ids, created_by, created_at, expires_at, revoked_at, last_used_at`
+
+func (r *APIKeyPostgresRepository) Insert(ctx context.Context, key domain.APIKey, hash []byte) (domain.APIKey, error) {
+	const query = `
+INSERT INTO timetable.api_keys (id, name, key_hash, key_prefix, scopes, class_ids, created_by, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, now(), $8)
+RETURNING ` + apiKeyColumns
+
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	classIDs, err := json.Marshal(key.ClassIDs)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	var expiresAt sql.NullTime
+	if key.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *key.ExpiresAt, Valid: true}
+	}
+
+	return scanAPIKey(r.execer.QueryRowContext(
+		ctx,
+		query,
+		key.ID,
+		key.Name,
+		hash,
+		key.Prefix,
+		scopes,
+		classIDs,
+		key.CreatedBy,
+		expiresAt,
+	))
+}
+
+func (r *APIKeyPostgresRepository) GetByHash(ctx context.Context, hash []byte) (domain.APIKey, error) {
+	const query = `
+SELECT ` + apiKeyColumns + `
+FROM timetable.api_keys
+WHERE key_hash = $1
+`
+
+	return scanAPIKey(r.execer.QueryRowContext(ctx, query, hash))
+}
+
+func (r *APIKeyPostgresRepository) List(ctx context.Context) ([]domain.APIKey, error) {
+	const query = `
+SELECT ` + apiKeyColumns + `
+FROM timetable.api_keys
+ORDER BY created_at DESC, id
+`
+
+	rows, err := r.execer.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyPostgresRepository) Revoke(ctx context.Context, id uuid.UUID) (bool, error) {
+	const query = `
+UPDATE timetable.api_keys
+SET revoked_at = now()
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+	result, err := r.execer.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// TouchLastUsed records use at most once a minute per key, so busy keys do
+// not rewrite their row on every request.
+func (r *APIKeyPostgresRepository) TouchLastUsed(ctx context.Context, id uuid.UUID) error {
+	const query = `
+UPDATE timetable.api_keys
+SET last_used_at = now()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+`
+
+	_, err := r.execer.ExecContext(ctx, query, id)
+	return err
+}
+
+func scanAPIKey(row rowScanner) (domain.APIKey, error) {
+	var key domain.APIKey
+	var scopes, classIDs []byte
+	var expiresAt, revokedAt, lastUsedAt sql.NullTime
+	if err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&scopes,
+		&classIDs,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&expiresAt,
+		&revokedAt,
+		&lastUsedAt,
+	); err != nil {
+		return domain.APIKey{}, err
+	}
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return domain.APIKey{}, err
+	}
+	if err := json.Unmarshal(classIDs, &key.ClassIDs); err != nil {
+		return domain.APIKey{}, err
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return key, nil
+}
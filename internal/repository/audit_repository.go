@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+)
+
+type AuditRepository interface {
+	Insert(ctx context.Context, entry domain.AuditEntry) error
+	ListByClass(ctx context.Context, classID uuid.UUID, limit int) ([]domain.AuditEntry, error)
+}
+
+type AuditPostgresRepository struct {
+	execer Execer
+}
+
+func NewAuditPostgresRepository(execer Execer) *AuditPostgresRepository {
+	return &AuditPostgresRepository{execer: execer}
+}
+
+func (r *AuditPostgresRepository) Insert(ctx context.Context, entry domain.AuditEntry) error {
+	const query = `
+INSERT INTO timetable.audit_log (id, occurred_at, actor_id, actor_kind, action, class_id, date, details)
+VALUES ($1, now(), $2, $3, $4, $5, $6, $7)
+`
+
+	var date sql.NullTime
+	if entry.Date != nil {
+		date = sql.NullTime{Time: *entry.Date, Valid: true}
+	}
+	classID := uuid.NullUUID{UUID: entry.ClassID, Valid: entry.ClassID != uuid.Nil}
+	actorKind := entry.ActorKind
+	if actorKind == "" {
+		actorKind = domain.ActorUser
+	}
+	details := []byte(entry.Details)
+	if len(details) == 0 {
+		details = []byte("{}")
+	}
+
+	_, err := r.execer.ExecContext(ctx, query, entry.ID, entry.ActorID, actorKind, entry.Action, classID, date, details)
+	return err
+}
+
+// ListByClass returns a class's entries, or the entries not about any class
+// when classID is uuid.Nil.
+func (r *AuditPostgresRepository) ListByClass(ctx context.Context, classID uuid.UUID, limit int) ([]domain.AuditEntry, error) {
+	const query = `
+SELECT id, occurred_at, actor_id, actor_kind, action, class_id, date, details
+FROM timetable.audit_log
+WHERE class_id IS NOT DISTINCT FROM $1
+ORDER BY occurred_at DESC, id
+LIMIT $2
+`
+
+	rows, err := r.execer.QueryContext(ctx, query, uuid.NullUUID{UUID: classID, Valid: classID != uuid.Nil}, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditEntry
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var classID uuid.NullUUID
+		var date sql.NullTime
+		var details []byte
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.OccurredAt,
+			&entry.ActorID,
+			&entry.ActorKind,
+			&entry.Action,
+			&classID,
+			&date,
+			&details,
+		); err != nil {
+			return nil, err
+		}
+		entry.ClassID = classID.UUID
+		if date.Valid {
+			entry.Date = &date.Time
+		}
+		entry.Details = details
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
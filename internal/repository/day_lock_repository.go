@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+)
+
+type DayLockRepository interface {
+	Upsert(ctx context.Context, lock domain.DayLock) (domain.DayLock, error)
+	Delete(ctx context.Context, classID uuid.UUID, date time.Time, slotIndex int) (bool, error)
+	ListByDate(ctx context.Context, classID uuid.UUID, date time.Time) ([]domain.DayLock, error)
+}
+
+type DayLockPostgresRepository struct {
+	execer Execer
+}
+
+func NewDayLockPostgresRepository(execer Execer) *DayLockPostgresRepository {
+	return &DayLockPostgresRepository{execer: execer}
+}
+
+func (r *DayLockPostgresRepository) Upsert(ctx context.Context, lock domain.DayLock) (domain.DayLock, error) {
+	const query = `
+INSERT INTO timetable.day_locks (class_id, date, slot_index, locked_by, locked_at, reason)
+VALUES ($1, $2, $3, $4, now(), $5)
+ON CONFLICT (class_id, date, slot_index)
+DO UPDATE SET
+	locked_by = EXCLUDED.locked_by,
+	locked_at = EXCLUDED.locked_at,
+	reason = EXCLUDED.reason
+RETURNING class_id, date, slot_index, locked_by, locked_at, reason
+`
+
+	var stored domain.DayLock
+	if err := r.execer.QueryRowContext(
+		ctx,
+		query,
+		lock.ClassID,
+		lock.Date,
+		lock.SlotIndex,
+		lock.LockedBy,
+		lock.Reason,
+	).Scan(
+		&stored.ClassID,
+		&stored.Date,
+		&stored.SlotIndex,
+		&stored.LockedBy,
+		&stored.LockedAt,
+		&stored.Reason,
+	); err != nil {
+		return domain.DayLock{}, err
+	}
+	return stored, nil
+}
+
+func (r *DayLockPostgresRepository) Delete(ctx context.Context, classID uuid.UUID, date time.Time, slotIndex int) (bool, error) {
+	const query = `
+DELETE FROM timetable.day_locks
+WHERE class_id = $1 AND date = $2 AND slot_index = $3
+`
+
+	result, err := r.execer.ExecContext(ctx, query, classID, date, slotIndex)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *DayLockPostgresRepository) ListByDate(ctx context.Context, classID uuid.UUID, date time.Time) ([]domain.DayLock, error) {
+	const query = `
+SELECT class_id, date, slot_index, locked_by, locked_at, reason
+FROM timetable.day_locks
+WHERE class_id = $1 AND date = $2
+ORDER BY slot_index
+`
+
+	rows, err := r.execer.QueryContext(ctx, query, classID, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locks []domain.DayLock
+	for rows.Next() {
+		var lock domain.DayLock
+		if err := rows.Scan(
+			&lock.ClassID,
+			&lock.Date,
+			&lock.SlotIndex,
+			&lock.LockedBy,
+			&lock.LockedAt,
+			&lock.Reason,
+		); err != nil {
+			return nil, err
+		}
+		locks = append(locks, lock)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return locks, nil
+}
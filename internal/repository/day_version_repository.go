@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DayVersionRepository interface {
+	Get(ctx context.Context, classID uuid.UUID, date time.Time) (int64, error)
+	Bump(ctx context.Context, classID uuid.UUID, date time.Time) (int64, error)
+	BumpIfMatch(ctx context.Context, classID uuid.UUID, date time.Time, expected int64) (int64, bool, error)
+}
+
+type DayVersionPostgresRepository struct {
+	execer Execer
+}
+
+func NewDayVersionPostgresRepository(execer Execer) *DayVersionPostgresRepository {
+	return &DayVersionPostgresRepository{execer: execer}
+}
+
+func (r *DayVersionPostgresRepository) Get(ctx context.Context, classID uuid.UUID, date time.Time) (int64, error) {
+	const query = `
+SELECT version
+FROM timetable.day_versions
+WHERE class_id = $1 AND date = $2
+`
+
+	var version int64
+	if err := r.execer.QueryRowContext(ctx, query, classID, date).Scan(&version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return version, nil
+}
+
+func (r *DayVersionPostgresRepository) Bump(ctx context.Context, classID uuid.UUID, date time.Time) (int64, error) {
+	const query = `
+INSERT INTO timetable.day_versions (class_id, date, version, updated_at)
+VALUES ($1, $2, 1, now())
+ON CONFLICT (class_id, date)
+DO UPDATE SET
+	version = timetable.day_versions.version + 1,
+	updated_at = now()
+RETURNING version
+`
+
+	var version int64
+	if err := r.execer.QueryRowContext(ctx, query, classID, date).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// BumpIfMatch increments the day version only when it currently equals
+// expected. Version 0 stands for a day that has never been written.
+func (r *DayVersionPostgresRepository) BumpIfMatch(ctx context.Context, classID uuid.UUID, date time.Time, expected int64) (int64, bool, error) {
+	const insertQuery = `
+INSERT INTO timetable.day_versions (class_id, date, version, updated_at)
+VALUES ($1, $2, 1, now())
+ON CONFLICT (class_id, date) DO NOTHING
+RETURNING version
+`
+	const updateQuery = `
+UPDATE timetable.day_versions
+SET version = version + 1, updated_at = now()
+WHERE class_id = $1 AND date = $2 AND version = $3
+RETURNING version
+`
+
+	var row *sql.Row
+	if expected == 0 {
+		row = r.execer.QueryRowContext(ctx, insertQuery, classID, date)
+	} else {
+		row = r.execer.QueryRowContext(ctx, updateQuery, classID, date, expected)
+	}
+
+	var version int64
+	if err := row.Scan(&version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return version, true, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRevisionRepository tracks, per class and weekday, a revision that
+// increases with every change to the default slots, and the next slot index
+// to hand out. Slot indices are never reused, so overrides keep pointing at
+// the slot they were written for.
+type DefaultRevisionRepository interface {
+	Get(ctx context.Context, classID uuid.UUID, weekday int) (int64, error)
+	Share(ctx context.Context, classID uuid.UUID, weekday int) (int64, error)
+	Bump(ctx context.Context, classID uuid.UUID, weekday int) (int64, error)
+	NextSlotIndex(ctx context.Context, classID uuid.UUID, weekday int) (int, error)
+}
+
+type DefaultRevisionPostgresRepository struct {
+	execer Execer
+}
+
+func NewDefaultRevisionPostgresRepository(execer Execer) *DefaultRevisionPostgresRepository {
+	return &DefaultRevisionPostgresRepository{execer: execer}
+}
+
+func (r *DefaultRevisionPostgresRepository) Get(ctx context.Context, classID uuid.UUID, weekday int) (int64, error) {
+	const query = `
+SELECT revision
+FROM timetable.default_revisions
+WHERE class_id = $1 AND weekday = $2
+`
+
+	var revision int64
+	if err := r.execer.QueryRowContext(ctx, query, classID, weekday).Scan(&revision); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return revision, nil
+}
+
+// Share returns the revision and holds a share lock on it until the
+// transaction ends, so the defaults cannot change underneath a writer that
+// checked the day version against it.
+func (r *DefaultRevisionPostgresRepository) Share(ctx context.Context, classID uuid.UUID, weekday int) (int64, error) {
+	if err := r.ensure(ctx, classID, weekday); err != nil {
+		return 0, err
+	}
+
+	const query = `
+SELECT revision
+FROM timetable.default_revisions
+WHERE class_id = $1 AND weekday = $2
+FOR SHARE
+`
+
+	var revision int64
+	if err := r.execer.QueryRowContext(ctx, query, classID, weekday).Scan(&revision); err != nil {
+		return 0, err
+	}
+	return revision, nil
+}
+
+func (r *DefaultRevisionPostgresRepository) Bump(ctx context.Context, classID uuid.UUID, weekday int) (int64, error) {
+	const query = `
+INSERT INTO timetable.default_revisions (class_id, weekday, revision, updated_at)
+VALUES ($1, $2, 1, now())
+ON CONFLICT (class_id, weekday)
+DO UPDATE SET
+	revision = timetable.default_revisions.revision + 1,
+	updated_at = now()
+RETURNING revision
+`
+
+	var revision int64
+	if err := r.execer.QueryRowContext(ctx, query, classID, weekday).Scan(&revision); err != nil {
+		return 0, err
+	}
+	return revision, nil
+}
+
+// NextSlotIndex hands out a slot index for a new default slot. It skips
+// indices used by any override of the weekday, past days included, so a new
+// slot never takes over the index of a slot an override added.
+func (r *DefaultRevisionPostgresRepository) NextSlotIndex(ctx context.Context, classID uuid.UUID, weekday int) (int, error) {
+	if err := r.ensure(ctx, classID, weekday); err != nil {
+		return 0, err
+	}
+
+	const query = `
+UPDATE timetable.default_revisions
+SET next_slot_index = GREATEST(
+	next_slot_index,
+	(
+		SELECT COALESCE(max(slot_index), 0) + 1
+		FROM timetable.daily_overrides
+		WHERE class_id = $1 AND EXTRACT(ISODOW FROM date) = $2
+	)
+) + 1
+WHERE class_id = $1 AND weekday = $2
+RETURNING next_slot_index - 1
+`
+
+	var slotIndex int
+	if err := r.execer.QueryRowContext(ctx, query, classID, weekday).Scan(&slotIndex); err != nil {
+		return 0, err
+	}
+	return slotIndex, nil
+}
+
+func (r *DefaultRevisionPostgresRepository) ensure(ctx context.Context, classID uuid.UUID, weekday int) error {
+	const query = `
+INSERT INTO timetable.default_revisions (class_id, weekday)
+VALUES ($1, $2)
+ON CONFLICT (class_id, weekday) DO NOTHING
+`
+
+	_, err := r.execer.ExecContext(ctx, query, classID, weekday)
+	return err
+}
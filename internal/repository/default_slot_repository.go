@@ -11,6 +11,9 @@ import (
 
 type DefaultSlotRepository interface {
 	ListByWeekday(ctx context.Context, classID uuid.UUID, weekday int) ([]domain.DefaultSlot, error)
+	Upsert(ctx context.Context, slot domain.DefaultSlot) error
+	Delete(ctx context.Context, classID uuid.UUID, weekday int, startTime time.Time) (bool, error)
+	ListClassIDsByWeekday(ctx context.Context, weekday int) ([]uuid.UUID, error)
 }
 
 type DefaultSlotPostgresRepository struct {
@@ -23,7 +26,7 @@ func NewDefaultSlotPostgresRepository(execer Execer) *DefaultSlotPostgresReposit
 
 func (r *DefaultSlotPostgresRepository) ListByWeekday(ctx context.Context, classID uuid.UUID, weekday int) ([]domain.DefaultSlot, error) {
 	const query = `
-SELECT class_id, weekday, course_code, start_time, end_time, venue
+SELECT class_id, weekday, slot_index, course_code, start_time, end_time, venue, faculty_id
 FROM timetable.default_slots
 WHERE class_id = $1 AND weekday = $2
 ORDER BY start_time ASC
@@ -43,10 +46,12 @@ ORDER BY start_time ASC
 		if err := rows.Scan(
 			&slot.ClassID,
 			&slot.Weekday,
+			&slot.SlotIndex,
 			&slot.CourseCode,
 			&startTime,
 			&endTime,
 			&slot.Venue,
+			&slot.FacultyID,
 		); err != nil {
 			return nil, err
 		}
@@ -60,3 +65,87 @@ ORDER BY start_time ASC
 
 	return slots, nil
 }
+
+func (r *DefaultSlotPostgresRepository) Upsert(ctx context.Context, slot domain.DefaultSlot) error {
+	const query = `
+INSERT INTO timetable.default_slots (
+	class_id,
+	weekday,
+	slot_index,
+	course_code,
+	start_time,
+	end_time,
+	venue,
+	faculty_id
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (class_id, weekday, start_time)
+DO UPDATE SET
+	course_code = EXCLUDED.course_code,
+	end_time = EXCLUDED.end_time,
+	venue = EXCLUDED.venue,
+	faculty_id = EXCLUDED.faculty_id
+`
+
+	_, err := r.execer.ExecContext(
+		ctx,
+		query,
+		slot.ClassID,
+		slot.Weekday,
+		slot.SlotIndex,
+		slot.CourseCode,
+		slot.StartTime,
+		slot.EndTime,
+		slot.Venue,
+		slot.FacultyID,
+	)
+	return err
+}
+
+func (r *DefaultSlotPostgresRepository) Delete(ctx context.Context, classID uuid.UUID, weekday int, startTime time.Time) (bool, error) {
+	const query = `
+DELETE FROM timetable.default_slots
+WHERE class_id = $1 AND weekday = $2 AND start_time = $3
+`
+
+	result, err := r.execer.ExecContext(ctx, query, classID, weekday, startTime)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *DefaultSlotPostgresRepository) ListClassIDsByWeekday(ctx context.Context, weekday int) ([]uuid.UUID, error) {
+	const query = `
+SELECT DISTINCT class_id
+FROM timetable.default_slots
+WHERE weekday = $1
+ORDER BY class_id
+`
+
+	return listClassIDs(ctx, r.execer, query, weekday)
+}
+
+func listClassIDs(ctx context.Context, execer Execer, query string, args ...any) ([]uuid.UUID, error) {
+	rows, err := execer.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classIDs []uuid.UUID
+	for rows.Next() {
+		var classID uuid.UUID
+		if err := rows.Scan(&classID); err != nil {
+			return nil, err
+		}
+		classIDs = append(classIDs, classID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return classIDs, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+)
+
+type DelegationRepository interface {
+	Insert(ctx context.Context, delegation domain.Delegation) (domain.Delegation, error)
+	Get(ctx context.Context, id uuid.UUID) (domain.Delegation, error)
+	Revoke(ctx context.Context, id uuid.UUID, revokedBy uuid.UUID) (bool, error)
+	ListCurrentByClass(ctx context.Context, classID uuid.UUID, from time.Time) ([]domain.Delegation, error)
+	HasActive(ctx context.Context, delegateID uuid.UUID, classID uuid.UUID, date time.Time) (bool, error)
+}
+
+type DelegationPostgresRepository struct {
+	execer Execer
+}
+
+func NewDelegationPostgresRepository(execer Execer) *DelegationPostgresRepository {
+	return &DelegationPostgresRepository{execer: execer}
+}
+
+const delegationColumns = `id, class_id, delegate_id, granted_by, starts_on, ends_on, created_at, revoked_at, revoked_by`
+
+func (r *DelegationPostgresRepository) Insert(ctx context.Context, delegation domain.Delegation) (domain.Delegation, error) {
+	const query = `
+INSERT INTO timetable.delegations (id, class_id, delegate_id, granted_by, starts_on, ends_on, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, now())
+RETURNING ` + delegationColumns
+
+	return scanDelegation(r.execer.QueryRowContext(
+		ctx,
+		query,
+		delegation.ID,
+		delegation.ClassID,
+		delegation.DelegateID,
+		delegation.GrantedBy,
+		delegation.StartsOn,
+		delegation.EndsOn,
+	))
+}
+
+func (r *DelegationPostgresRepository) Get(ctx context.Context, id uuid.UUID) (domain.Delegation, error) {
+	const query = `
+SELECT ` + delegationColumns + `
+FROM timetable.delegations
+WHERE id = $1
+`
+
+	return scanDelegation(r.execer.QueryRowContext(ctx, query, id))
+}
+
+func (r *DelegationPostgresRepository) Revoke(ctx context.Context, id uuid.UUID, revokedBy uuid.UUID) (bool, error) {
+	const query = `
+UPDATE timetable.delegations
+SET revoked_at = now(), revoked_by = $2
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+	result, err := r.execer.ExecContext(ctx, query, id, revokedBy)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ListCurrentByClass returns unrevoked delegations that have not ended
+// before from, ordered by start date.
+func (r *DelegationPostgresRepository) ListCurrentByClass(ctx context.Context, classID uuid.UUID, from time.Time) ([]domain.Delegation, error) {
+	const query = `
+SELECT ` + delegationColumns + `
+FROM timetable.delegations
+WHERE class_id = $1 AND revoked_at IS NULL AND ends_on >= $2
+ORDER BY starts_on, created_at
+`
+
+	rows, err := r.execer.QueryContext(ctx, query, classID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var delegations []domain.Delegation
+	for rows.Next() {
+		delegation, err := scanDelegation(rows)
+		if err != nil {
+			return nil, err
+		}
+		delegations = append(delegations, delegation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return delegations, nil
+}
+
+func (r *DelegationPostgresRepository) HasActive(ctx context.Context, delegateID uuid.UUID, classID uuid.UUID, date time.Time) (bool, error) {
+	const query = `
+SELECT EXISTS (
+	SELECT 1
+	FROM timetable.delegations
+	WHERE delegate_id = $1
+	  AND class_id = $2
+	  AND revoked_at IS NULL
+	  AND starts_on <= $3
+	  AND ends_on >= $3
+)
+`
+
+	var active bool
+	if err := r.execer.QueryRowContext(ctx, query, delegateID, classID, date).Scan(&active); err != nil {
+		return false, err
+	}
+	return active, nil
+}
+
+func scanDelegation(row rowScanner) (domain.Delegation, error) {
+	var delegation domain.Delegation
+	var revokedAt sql.NullTime
+	var revokedBy uuid.NullUUID
+	if err := row.Scan(
+		&delegation.ID,
+		&delegation.ClassID,
+		&delegation.DelegateID,
+		&delegation.GrantedBy,
+		&delegation.StartsOn,
+		&delegation.EndsOn,
+		&delegation.CreatedAt,
+		&revokedAt,
+		&revokedBy,
+	); err != nil {
+		return domain.Delegation{}, err
+	}
+	if revokedAt.Valid {
+		delegation.RevokedAt = &revokedAt.Time
+	}
+	if revokedBy.Valid {
+		delegation.RevokedBy = &revokedBy.UUID
+	}
+	return delegation, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+)
+
+type EditPolicyRepository interface {
+	Get(ctx context.Context, classID uuid.UUID) (domain.EditPolicy, error)
+	Upsert(ctx context.Context, policy domain.EditPolicy) (domain.EditPolicy, error)
+}
+
+type EditPolicyPostgresRepository struct {
+	execer Execer
+}
+
+func NewEditPolicyPostgresRepository(execer Execer) *EditPolicyPostgresRepository {
+	return &EditPolicyPostgresRepository{execer: execer}
+}
+
+// Get returns the class's policy, or an empty policy without a cutoff when
+// none is stored.
+func (r *EditPolicyPostgresRepository) Get(ctx context.Context, classID uuid.UUID) (domain.EditPolicy, error) {
+	const query = `
+SELECT class_id, cutoff_minutes, updated_at
+FROM timetable.edit_policies
+WHERE class_id = $1
+`
+
+	policy, err := scanEditPolicy(r.execer.QueryRowContext(ctx, query, classID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.EditPolicy{ClassID: classID}, nil
+	}
+	return policy, err
+}
+
+func (r *EditPolicyPostgresRepository) Upsert(ctx context.Context, policy domain.EditPolicy) (domain.EditPolicy, error) {
+	const query = `
+INSERT INTO timetable.edit_policies (class_id, cutoff_minutes, updated_at)
+VALUES ($1, $2, now())
+ON CONFLICT (class_id)
+DO UPDATE SET
+	cutoff_minutes = EXCLUDED.cutoff_minutes,
+	updated_at = EXCLUDED.updated_at
+RETURNING class_id, cutoff_minutes, updated_at
+`
+
+	var cutoff sql.NullInt32
+	if policy.CutoffMinutes != nil {
+		cutoff = sql.NullInt32{Int32: int32(*policy.CutoffMinutes), Valid: true}
+	}
+	return scanEditPolicy(r.execer.QueryRowContext(ctx, query, policy.ClassID, cutoff))
+}
+
+func scanEditPolicy(row rowScanner) (domain.EditPolicy, error) {
+	var policy domain.EditPolicy
+	var cutoff sql.NullInt32
+	if err := row.Scan(&policy.ClassID, &cutoff, &policy.UpdatedAt); err != nil {
+		return domain.EditPolicy{}, err
+	}
+	if cutoff.Valid {
+		minutes := int(cutoff.Int32)
+		policy.CutoffMinutes = &minutes
+	}
+	return policy, nil
+}
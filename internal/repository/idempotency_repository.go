@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+)
+
+type IdempotencyRepository interface {
+	Claim(ctx context.Context, requesterID uuid.UUID, key string, fingerprint string, ttl time.Duration, lease time.Duration) (bool, error)
+	Get(ctx context.Context, requesterID uuid.UUID, key string) (domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, requesterID uuid.UUID, key string, response json.RawMessage) error
+	Fail(ctx context.Context, requesterID uuid.UUID, key string, outcome json.RawMessage) error
+	Release(ctx context.Context, requesterID uuid.UUID, key string) error
+	DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type IdempotencyPostgresRepository struct {
+	execer Execer
+}
+
+func NewIdempotencyPostgresRepository(execer Execer) *IdempotencyPostgresRepository {
+	return &IdempotencyPostgresRepository{execer: execer}
+}
+
+// Claim records a new key for the requester. It reports false when the key is
+// already held by a live record; a concurrent claim blocks until the holding
+// transaction finishes. Records older than ttl are taken over, as are records
+// still without an outcome after lease, whose request must have died.
+func (r *IdempotencyPostgresRepository) Claim(ctx context.Context, requesterID uuid.UUID, key string, fingerprint string, ttl time.Duration, lease time.Duration) (bool, error) {
+	const query = `
+INSERT INTO timetable.idempotency_keys (requester_id, key, fingerprint, response, error, created_at)
+VALUES ($1, $2, $3, NULL, NULL, now())
+ON CONFLICT (requester_id, key)
+DO UPDATE SET
+	fingerprint = EXCLUDED.fingerprint,
+	response = NULL,
+	error = NULL,
+	created_at = now()
+WHERE timetable.idempotency_keys.created_at < now() - make_interval(secs => $4)
+	OR (
+		timetable.idempotency_keys.response IS NULL
+		AND timetable.idempotency_keys.error IS NULL
+		AND timetable.idempotency_keys.created_at < now() - make_interval(secs => $5)
+	)
+`
+
+	result, err := r.execer.ExecContext(ctx, query, requesterID, key, fingerprint, ttl.Seconds(), lease.Seconds())
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *IdempotencyPostgresRepository) Get(ctx context.Context, requesterID uuid.UUID, key string) (domain.IdempotencyRecord, error) {
+	const query = `
+SELECT requester_id, key, fingerprint, response, error, created_at
+FROM timetable.idempotency_keys
+WHERE requester_id = $1 AND key = $2
+`
+
+	var record domain.IdempotencyRecord
+	var response, outcome []byte
+	if err := r.execer.QueryRowContext(ctx, query, requesterID, key).Scan(
+		&record.RequesterID,
+		&record.Key,
+		&record.Fingerprint,
+		&response,
+		&outcome,
+		&record.CreatedAt,
+	); err != nil {
+		return domain.IdempotencyRecord{}, err
+	}
+	record.Response = response
+	record.Error = outcome
+	return record, nil
+}
+
+func (r *IdempotencyPostgresRepository) Complete(ctx context.Context, requesterID uuid.UUID, key string, response json.RawMessage) error {
+	const query = `
+UPDATE timetable.idempotency_keys
+SET response = $3
+WHERE requester_id = $1 AND key = $2
+`
+
+	_, err := r.execer.ExecContext(ctx, query, requesterID, key, []byte(response))
+	return err
+}
+
+// Fail completes the key with the error its request failed with, unless a
+// result was already stored for it.
+func (r *IdempotencyPostgresRepository) Fail(ctx context.Context, requesterID uuid.UUID, key string, outcome json.RawMessage) error {
+	const query = `
+UPDATE timetable.idempotency_keys
+SET error = $3
+WHERE requester_id = $1 AND key = $2 AND response IS NULL
+`
+
+	_, err := r.execer.ExecContext(ctx, query, requesterID, key, []byte(outcome))
+	return err
+}
+
+// Release frees a key whose request failed without an outcome worth
+// replaying, so that a retry runs the request again.
+func (r *IdempotencyPostgresRepository) Release(ctx context.Context, requesterID uuid.UUID, key string) error {
+	const query = `
+DELETE FROM timetable.idempotency_keys
+WHERE requester_id = $1 AND key = $2 AND response IS NULL AND error IS NULL
+`
+
+	_, err := r.execer.ExecContext(ctx, query, requesterID, key)
+	return err
+}
+
+func (r *IdempotencyPostgresRepository) DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	const query = `
+DELETE FROM timetable.idempotency_keys
+WHERE created_at < $1
+`
+
+	result, err := r.execer.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"service-timetable/internal/domain"
+)
+
+type LeaderLeaseRepository interface {
+	TryAcquire(ctx context.Context, name string, holder string, ttl time.Duration) (domain.LeaderLease, bool, error)
+	Get(ctx context.Context, name string) (domain.LeaderLease, error)
+	Release(ctx context.Context, name string, holder string) error
+}
+
+type LeaderLeasePostgresRepository struct {
+	execer Execer
+}
+
+func NewLeaderLeasePostgresRepository(execer Execer) *LeaderLeasePostgresRepository {
+	return &LeaderLeasePostgresRepository{execer: execer}
+}
+
+// TryAcquire takes the lease when it is free or expired, or renews it when
+// holder already has it. It reports false, with the current lease, when
+// another holder has it. Expiry uses the database clock, so replica clocks
+// need not agree.
+func (r *LeaderLeasePostgresRepository) TryAcquire(ctx context.Context, name string, holder string, ttl time.Duration) (domain.LeaderLease, bool, error) {
+	const query = `
+INSERT INTO timetable.leader_leases (name, holder, acquired_at, renewed_at, expires_at)
+VALUES ($1, $2, now(), now(), now() + make_interval(secs => $3))
+ON CONFLICT (name)
+DO UPDATE SET
+	holder = EXCLUDED.holder,
+	acquired_at = CASE
+		WHEN leader_leases.holder = EXCLUDED.holder THEN leader_leases.acquired_at
+		ELSE EXCLUDED.acquired_at
+	END,
+	renewed_at = EXCLUDED.renewed_at,
+	expires_at = EXCLUDED.expires_at
+WHERE leader_leases.holder = EXCLUDED.holder
+	OR leader_leases.expires_at < now()
+RETURNING name, holder, acquired_at, renewed_at, expires_at
+`
+
+	lease, err := scanLeaderLease(r.execer.QueryRowContext(ctx, query, name, holder, ttl.Seconds()))
+	if err == nil {
+		return lease, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return domain.LeaderLease{}, false, err
+	}
+	lease, err = r.Get(ctx, name)
+	return lease, false, err
+}
+
+func (r *LeaderLeasePostgresRepository) Get(ctx context.Context, name string) (domain.LeaderLease, error) {
+	const query = `
+SELECT name, holder, acquired_at, renewed_at, expires_at
+FROM timetable.leader_leases
+WHERE name = $1
+`
+
+	return scanLeaderLease(r.execer.QueryRowContext(ctx, query, name))
+}
+
+// Release gives up the lease so that another replica can take over without
+// waiting for it to expire.
+func (r *LeaderLeasePostgresRepository) Release(ctx context.Context, name string, holder string) error {
+	const query = `
+DELETE FROM timetable.leader_leases
+WHERE name = $1 AND holder = $2
+`
+
+	_, err := r.execer.ExecContext(ctx, query, name, holder)
+	return err
+}
+
+func scanLeaderLease(row rowScanner) (domain.LeaderLease, error) {
+	var lease domain.LeaderLease
+	err := row.Scan(&lease.Name, &lease.Holder, &lease.AcquiredAt, &lease.RenewedAt, &lease.ExpiresAt)
+	return lease, err
+}
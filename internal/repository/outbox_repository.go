@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -11,6 +13,9 @@ import (
 
 type OutboxRepository interface {
 	Insert(ctx context.Context, event domain.TimetableEvent) error
+	ListAfter(ctx context.Context, afterID *uuid.UUID, limit int) ([]domain.OutboxEvent, error)
+	Backlog(ctx context.Context) (int, time.Time, error)
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
 type OutboxPostgresRepository struct {
@@ -40,3 +45,93 @@ INSERT INTO timetable.outbox_events (
 	_, err = r.execer.ExecContext(ctx, query, uuid.New(), event.EventType, payload)
 	return err
 }
+
+// ListAfter returns events in creation order, starting after afterID when it
+// is set. An unknown afterID yields sql.ErrNoRows.
+func (r *OutboxPostgresRepository) ListAfter(ctx context.Context, afterID *uuid.UUID, limit int) ([]domain.OutboxEvent, error) {
+	const firstPage = `
+SELECT id, event_type, payload, created_at, published
+FROM timetable.outbox_events
+ORDER BY created_at, id
+LIMIT $1
+`
+	const nextPage = `
+SELECT id, event_type, payload, created_at, published
+FROM timetable.outbox_events
+WHERE (created_at, id) > (
+	SELECT created_at, id
+	FROM timetable.outbox_events
+	WHERE id = $2
+)
+ORDER BY created_at, id
+LIMIT $1
+`
+
+	var rows *sql.Rows
+	var err error
+	if afterID == nil {
+		rows, err = r.execer.QueryContext(ctx, firstPage, limit)
+	} else {
+		const exists = `SELECT EXISTS (SELECT 1 FROM timetable.outbox_events WHERE id = $1)`
+		var found bool
+		if err := r.execer.QueryRowContext(ctx, exists, *afterID).Scan(&found); err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, sql.ErrNoRows
+		}
+		rows, err = r.execer.QueryContext(ctx, nextPage, limit, *afterID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		var event domain.OutboxEvent
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.EventType, &payload, &event.CreatedAt, &event.Published); err != nil {
+			return nil, err
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Backlog returns the number of unpublished events and the creation time of
+// the oldest, or the zero time when there are none.
+func (r *OutboxPostgresRepository) Backlog(ctx context.Context) (int, time.Time, error) {
+	const query = `
+SELECT count(*), min(created_at)
+FROM timetable.outbox_events
+WHERE published = false
+`
+
+	var count int
+	var oldest sql.NullTime
+	if err := r.execer.QueryRowContext(ctx, query).Scan(&count, &oldest); err != nil {
+		return 0, time.Time{}, err
+	}
+	return count, oldest.Time, nil
+}
+
+// DeletePublishedBefore removes published events created before the cutoff
+// and returns how many were removed. Unpublished events are always kept.
+func (r *OutboxPostgresRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	const query = `
+DELETE FROM timetable.outbox_events
+WHERE published = true AND created_at < $1
+`
+
+	result, err := r.execer.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -16,9 +16,15 @@ type Execer interface {
 	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 type DailyOverrideRepository interface {
 	Upsert(ctx context.Context, override domain.DailyOverride) error
 	ListByDate(ctx context.Context, classID uuid.UUID, date time.Time) ([]domain.DailyOverride, error)
+	ListClassIDsByDate(ctx context.Context, date time.Time) ([]uuid.UUID, error)
 }
 
 type DailyOverridePostgresRepository struct {
@@ -40,17 +46,20 @@ INSERT INTO timetable.daily_overrides (
 	start_time,
 	end_time,
 	venue,
+	faculty_id,
 	status,
 	created_at,
 	updated_at
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now(), now())
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, now(), now())
 ON CONFLICT (class_id, date, slot_index)
 DO UPDATE SET
 	course_code = EXCLUDED.course_code,
 	start_time = EXCLUDED.start_time,
 	end_time = EXCLUDED.end_time,
 	venue = EXCLUDED.venue,
+	faculty_id = EXCLUDED.faculty_id,
 	status = EXCLUDED.status,
+	version = timetable.daily_overrides.version + 1,
 	updated_at = now()
 `
 
@@ -65,6 +74,7 @@ DO UPDATE SET
 		override.StartTime,
 		override.EndTime,
 		override.Venue,
+		override.FacultyID,
 		override.Status,
 	)
 	return err
@@ -72,7 +82,7 @@ DO UPDATE SET
 
 func (r *DailyOverridePostgresRepository) ListByDate(ctx context.Context, classID uuid.UUID, date time.Time) ([]domain.DailyOverride, error) {
 	const query = `
-SELECT id, class_id, date, slot_index, course_code, start_time, end_time, venue, status
+SELECT id, class_id, date, slot_index, course_code, start_time, end_time, venue, faculty_id, status, version
 FROM timetable.daily_overrides
 WHERE class_id = $1 AND date = $2
 ORDER BY slot_index ASC
@@ -100,7 +110,9 @@ ORDER BY slot_index ASC
 			&startTime,
 			&endTime,
 			&venue,
+			&override.FacultyID,
 			&override.Status,
+			&override.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -124,3 +136,14 @@ ORDER BY slot_index ASC
 
 	return overrides, nil
 }
+
+func (r *DailyOverridePostgresRepository) ListClassIDsByDate(ctx context.Context, date time.Time) ([]uuid.UUID, error) {
+	const query = `
+SELECT DISTINCT class_id
+FROM timetable.daily_overrides
+WHERE date = $1
+ORDER BY class_id
+`
+
+	return listClassIDs(ctx, r.execer, query, date)
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SlotLifecycleRepository records which lifecycle events were emitted for
+// the slots of a date, so that each is emitted at most once.
+type SlotLifecycleRepository interface {
+	Claim(ctx context.Context, classID uuid.UUID, date time.Time, slotIndex int, eventType string) (bool, error)
+	ListByDate(ctx context.Context, classID uuid.UUID, date time.Time) (map[int][]string, error)
+	DeleteBefore(ctx context.Context, date time.Time) (int64, error)
+}
+
+type SlotLifecyclePostgresRepository struct {
+	execer Execer
+}
+
+func NewSlotLifecyclePostgresRepository(execer Execer) *SlotLifecyclePostgresRepository {
+	return &SlotLifecyclePostgresRepository{execer: execer}
+}
+
+// Claim records the event for the slot and reports false when it was
+// already recorded.
+func (r *SlotLifecyclePostgresRepository) Claim(ctx context.Context, classID uuid.UUID, date time.Time, slotIndex int, eventType string) (bool, error) {
+	const query = `
+INSERT INTO timetable.slot_lifecycle_events (class_id, date, slot_index, event_type, emitted_at)
+VALUES ($1, $2, $3, $4, now())
+ON CONFLICT (class_id, date, slot_index, event_type) DO NOTHING
+`
+
+	result, err := r.execer.ExecContext(ctx, query, classID, date, slotIndex, eventType)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ListByDate returns the event types emitted for each slot index of the date.
+func (r *SlotLifecyclePostgresRepository) ListByDate(ctx context.Context, classID uuid.UUID, date time.Time) (map[int][]string, error) {
+	const query = `
+SELECT slot_index, event_type
+FROM timetable.slot_lifecycle_events
+WHERE class_id = $1 AND date = $2
+`
+
+	rows, err := r.execer.QueryContext(ctx, query, classID, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emitted := make(map[int][]string)
+	for rows.Next() {
+		var slotIndex int
+		var eventType string
+		if err := rows.Scan(&slotIndex, &eventType); err != nil {
+			return nil, err
+		}
+		emitted[slotIndex] = append(emitted[slotIndex], eventType)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return emitted, nil
+}
+
+func (r *SlotLifecyclePostgresRepository) DeleteBefore(ctx context.Context, date time.Time) (int64, error) {
+	const query = `
+DELETE FROM timetable.slot_lifecycle_events
+WHERE date < $1
+`
+
+	result, err := r.execer.ExecContext(ctx, query, date)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SlotReminderRepository records which slots were reminded of, so that each
+// slot of a date is reminded of at most once per start time.
+type SlotReminderRepository interface {
+	Claim(ctx context.Context, classID uuid.UUID, date time.Time, slotIndex int, startTime time.Time) (bool, error)
+	DeleteBefore(ctx context.Context, date time.Time) (int64, error)
+}
+
+type SlotReminderPostgresRepository struct {
+	execer Execer
+}
+
+func NewSlotReminderPostgresRepository(execer Execer) *SlotReminderPostgresRepository {
+	return &SlotReminderPostgresRepository{execer: execer}
+}
+
+// Claim records a reminder for the slot starting at startTime and reports
+// false when one was already recorded.
+func (r *SlotReminderPostgresRepository) Claim(ctx context.Context, classID uuid.UUID, date time.Time, slotIndex int, startTime time.Time) (bool, error) {
+	const query = `
+INSERT INTO timetable.slot_reminders (class_id, date, slot_index, start_time, reminded_at)
+VALUES ($1, $2, $3, $4, now())
+ON CONFLICT (class_id, date, slot_index, start_time) DO NOTHING
+`
+
+	result, err := r.execer.ExecContext(ctx, query, classID, date, slotIndex, startTime)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *SlotReminderPostgresRepository) DeleteBefore(ctx context.Context, date time.Time) (int64, error) {
+	const query = `
+DELETE FROM timetable.slot_reminders
+WHERE date < $1
+`
+
+	result, err := r.execer.ExecContext(ctx, query, date)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"service-timetable/internal/tracing"
+)
+
+// tracedExecer starts a span for every query run through it. Spans end once
+// the query returns, before its rows are read.
+type tracedExecer struct {
+	execer Execer
+}
+
+func (e tracedExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	result, err := e.execer.ExecContext(ctx, query, args...)
+	recordSpanError(span, err)
+	return result, err
+}
+
+func (e tracedExecer) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	rows, err := e.execer.QueryContext(ctx, query, args...)
+	recordSpanError(span, err)
+	return rows, err
+}
+
+func (e tracedExecer) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	row := e.execer.QueryRowContext(ctx, query, args...)
+	if err := row.Err(); err != sql.ErrNoRows {
+		recordSpanError(span, err)
+	}
+	return row
+}
+
+var queryTarget = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE)\s+([a-z_][a-z0-9_.]*)`)
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	query = strings.TrimSpace(query)
+	operation, _, _ := strings.Cut(query, " ")
+	operation = strings.ToUpper(strings.TrimSpace(operation))
+	name := operation
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system.name", "postgresql"),
+		attribute.String("db.operation.name", operation),
+		attribute.String("db.query.text", query),
+	}
+	if match := queryTarget.FindStringSubmatch(query); match != nil {
+		name += " " + match[1]
+		attrs = append(attrs, attribute.String("db.collection.name", match[1]))
+	}
+	return tracing.Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
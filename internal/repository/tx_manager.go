@@ -3,13 +3,29 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"log/slog"
+	"time"
+
+	"service-timetable/internal/metrics"
+	"service-timetable/internal/tracing"
 )
 
 type TxRepositories struct {
 	Overrides    DailyOverrideRepository
 	Outbox       OutboxRepository
 	DefaultSlots DefaultSlotRepository
+	Revisions    DefaultRevisionRepository
 	Settings     AnnouncementSettingsRepository
+	Schedules    AnnouncementScheduleRepository
+	Reminders    SlotReminderRepository
+	Lifecycle    SlotLifecycleRepository
+	DayVersions  DayVersionRepository
+	Idempotency  IdempotencyRepository
+	Audit        AuditRepository
+	Delegations  DelegationRepository
+	DayLocks     DayLockRepository
+	EditPolicies EditPolicyRepository
+	APIKeys      APIKeyRepository
 }
 
 type TxManager interface {
@@ -24,17 +40,45 @@ func NewPostgresTxManager(db *sql.DB) *PostgresTxManager {
 	return &PostgresTxManager{db: db}
 }
 
-func (m *PostgresTxManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos TxRepositories) error) error {
+func (m *PostgresTxManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos TxRepositories) error) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WithTx")
+	defer func() {
+		recordSpanError(span, err)
+		span.End()
+	}()
+
 	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
 		return err
 	}
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		metrics.ObserveTx(elapsed, err != nil)
+		if err != nil {
+			slog.DebugContext(ctx, "transaction rolled back", "error", err, "duration_ms", elapsed.Milliseconds())
+			return
+		}
+		slog.DebugContext(ctx, "transaction committed", "duration_ms", elapsed.Milliseconds())
+	}()
 
+	execer := tracedExecer{execer: tx}
 	repos := TxRepositories{
-		Overrides:    NewDailyOverridePostgresRepository(tx),
-		Outbox:       NewOutboxPostgresRepository(tx),
-		DefaultSlots: NewDefaultSlotPostgresRepository(tx),
-		Settings:     NewAnnouncementSettingsPostgresRepository(tx),
+		Overrides:    NewDailyOverridePostgresRepository(execer),
+		Outbox:       NewOutboxPostgresRepository(execer),
+		DefaultSlots: NewDefaultSlotPostgresRepository(execer),
+		Revisions:    NewDefaultRevisionPostgresRepository(execer),
+		Settings:     NewAnnouncementSettingsPostgresRepository(execer),
+		Schedules:    NewAnnouncementSchedulePostgresRepository(execer),
+		Reminders:    NewSlotReminderPostgresRepository(execer),
+		Lifecycle:    NewSlotLifecyclePostgresRepository(execer),
+		DayVersions:  NewDayVersionPostgresRepository(execer),
+		Idempotency:  NewIdempotencyPostgresRepository(execer),
+		Audit:        NewAuditPostgresRepository(execer),
+		Delegations:  NewDelegationPostgresRepository(execer),
+		DayLocks:     NewDayLockPostgresRepository(execer),
+		EditPolicies: NewEditPolicyPostgresRepository(execer),
+		APIKeys:      NewAPIKeyPostgresRepository(execer),
 	}
 
 	if err := fn(ctx, repos); err != nil {
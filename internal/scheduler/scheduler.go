@@ -0,0 +1,279 @@
+// Package scheduler runs background jobs on cron schedules. Jobs only run on
+// the leader replica, never overlap with themselves, are bounded by their own
+// timeout and are recovered when they panic.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"service-timetable/internal/health"
+	"service-timetable/internal/logging"
+	"service-timetable/internal/metrics"
+	"service-timetable/internal/tracing"
+)
+
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeTimedOut  = "timed_out"
+	OutcomePanicked  = "panicked"
+)
+
+// maxIdle bounds how long the loop sleeps, so that its heartbeat stays fresh
+// between rare jobs.
+const maxIdle = 30 * time.Second
+
+// Job is a unit of scheduled work. Schedule is a five-field cron expression
+// (minute, hour, day of month, month, day of week) or a descriptor such as
+// "@hourly" or "@every 5m", evaluated in the local time zone. Run receives
+// the time the run was scheduled for.
+type Job struct {
+	Name     string
+	Schedule string
+	Timeout  time.Duration
+	Run      func(ctx context.Context, scheduled time.Time) error
+}
+
+// Run describes one finished run of a job.
+type Run struct {
+	ScheduledAt time.Time
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Outcome     string
+	Error       string
+}
+
+type JobStatus struct {
+	Name            string
+	Schedule        string
+	Timeout         time.Duration
+	Running         bool
+	NextRun         time.Time
+	LastRun         *Run
+	Runs            int
+	Failures        int
+	SkippedOverlaps int
+}
+
+type jobState struct {
+	job      Job
+	schedule cron.Schedule
+
+	running         bool
+	next            time.Time
+	lastRun         *Run
+	runs            int
+	failures        int
+	skippedOverlaps int
+}
+
+type Scheduler struct {
+	isLeader  func() bool
+	heartbeat *health.Heartbeat
+
+	mu   sync.Mutex
+	jobs []*jobState
+	wg   sync.WaitGroup
+}
+
+// New returns a scheduler that runs jobs only while isLeader reports true and
+// beats heartbeat every time its loop wakes up.
+func New(isLeader func() bool, heartbeat *health.Heartbeat) *Scheduler {
+	return &Scheduler{isLeader: isLeader, heartbeat: heartbeat}
+}
+
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil || job.Timeout <= 0 {
+		return fmt.Errorf("job %q needs a name, a run function and a timeout", job.Name)
+	}
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: invalid schedule %q: %w", job.Name, job.Schedule, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.jobs {
+		if existing.job.Name == job.Name {
+			return fmt.Errorf("job %s is already registered", job.Name)
+		}
+	}
+	s.jobs = append(s.jobs, &jobState{job: job, schedule: schedule})
+	return nil
+}
+
+// Run starts due jobs until ctx is done, then waits for running jobs to
+// finish. Running jobs are not cancelled on shutdown; their timeouts bound
+// the wait.
+func (s *Scheduler) Run(ctx context.Context) {
+	now := time.Now()
+	s.mu.Lock()
+	for _, state := range s.jobs {
+		state.next = state.schedule.Next(now)
+	}
+	s.mu.Unlock()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		s.heartbeat.Beat(time.Now(), nil)
+		timer.Reset(s.untilNext())
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "scheduler stopping, waiting for running jobs")
+			s.wg.Wait()
+			return
+		case <-timer.C:
+		}
+		s.dispatchDue(ctx, time.Now())
+	}
+}
+
+func (s *Scheduler) untilNext() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wait := maxIdle
+	for _, state := range s.jobs {
+		if until := time.Until(state.next); until < wait {
+			wait = max(until, 0)
+		}
+	}
+	return wait
+}
+
+func (s *Scheduler) dispatchDue(ctx context.Context, now time.Time) {
+	leader := s.isLeader()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, state := range s.jobs {
+		if state.next.After(now) {
+			continue
+		}
+		scheduled := state.next
+		state.next = state.schedule.Next(now)
+		if !leader {
+			continue
+		}
+		if state.running {
+			state.skippedOverlaps++
+			metrics.JobSkipped(state.job.Name)
+			slog.WarnContext(ctx, "job still running, skipping run", "job", state.job.Name, "scheduled_at", scheduled)
+			continue
+		}
+		state.running = true
+		s.wg.Add(1)
+		go s.run(ctx, state, scheduled)
+	}
+}
+
+func (s *Scheduler) run(parent context.Context, state *jobState, scheduled time.Time) {
+	defer s.wg.Done()
+
+	ctx := logging.With(context.WithoutCancel(parent),
+		slog.String("job", state.job.Name),
+		slog.String("run_id", uuid.NewString()),
+	)
+	ctx, cancel := context.WithTimeout(ctx, state.job.Timeout)
+	defer cancel()
+	ctx, span := tracing.Tracer().Start(ctx, "job "+state.job.Name, trace.WithAttributes(
+		attribute.String("job.name", state.job.Name),
+		attribute.String("job.scheduled_at", scheduled.UTC().Format(time.RFC3339)),
+	))
+	defer span.End()
+
+	started := time.Now()
+	err := runRecovered(ctx, state.job, scheduled)
+	finished := time.Now()
+
+	outcome := OutcomeSucceeded
+	var panicErr *panicError
+	switch {
+	case err == nil:
+	case errors.As(err, &panicErr):
+		outcome = OutcomePanicked
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		outcome = OutcomeTimedOut
+	default:
+		outcome = OutcomeFailed
+	}
+
+	last := &Run{ScheduledAt: scheduled, StartedAt: started, FinishedAt: finished, Outcome: outcome}
+	if err != nil {
+		last.Error = err.Error()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "job failed", "outcome", outcome, "error", err, "duration_ms", finished.Sub(started).Milliseconds())
+	} else {
+		slog.DebugContext(ctx, "job finished", "duration_ms", finished.Sub(started).Milliseconds())
+	}
+	metrics.ObserveJobRun(state.job.Name, outcome, finished.Sub(started))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state.running = false
+	state.lastRun = last
+	state.runs++
+	if err != nil {
+		state.failures++
+	}
+}
+
+type panicError struct {
+	value any
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+func runRecovered(ctx context.Context, job Job, scheduled time.Time) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.ErrorContext(ctx, "job panicked", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+			err = &panicError{value: recovered}
+		}
+	}()
+	return job.Run(ctx, scheduled)
+}
+
+// Jobs returns the status of every job in registration order.
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, state := range s.jobs {
+		status := JobStatus{
+			Name:            state.job.Name,
+			Schedule:        state.job.Schedule,
+			Timeout:         state.job.Timeout,
+			Running:         state.running,
+			NextRun:         state.next,
+			Runs:            state.runs,
+			Failures:        state.failures,
+			SkippedOverlaps: state.skippedOverlaps,
+		}
+		if state.lastRun != nil {
+			last := *state.lastRun
+			status.LastRun = &last
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (s *Scheduler) IsLeader() bool {
+	return s.isLeader()
+}
@@ -0,0 +1,194 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"service-timetable/internal/health"
+)
+
+// blockingJob returns a job that signals started when it runs and then waits
+// for release or its context.
+func blockingJob(name string, timeout time.Duration) (job Job, started chan struct{}, release chan struct{}) {
+	started = make(chan struct{}, 1)
+	release = make(chan struct{})
+	job = Job{
+		Name:     name,
+		Schedule: "* * * * *",
+		Timeout:  timeout,
+		Run: func(ctx context.Context, scheduled time.Time) error {
+			started <- struct{}{}
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+	return job, started, release
+}
+
+func newTestScheduler(t *testing.T, jobs ...Job) *Scheduler {
+	t.Helper()
+	s := New(func() bool { return true }, &health.Heartbeat{})
+	for _, job := range jobs {
+		if err := s.Register(job); err != nil {
+			t.Fatalf("Register(%s): %v", job.Name, err)
+		}
+	}
+	return s
+}
+
+func waitStarted(t *testing.T, started <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("job did not start")
+	}
+}
+
+func TestDispatchSkipsOverlappingRuns(t *testing.T) {
+	job, started, release := blockingJob("slow", time.Minute)
+	s := newTestScheduler(t, job)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.Local)
+
+	s.dispatchDue(ctx, now)
+	waitStarted(t, started)
+	s.dispatchDue(ctx, now.Add(time.Minute))
+	s.dispatchDue(ctx, now.Add(2*time.Minute))
+	close(release)
+	s.wg.Wait()
+
+	select {
+	case <-started:
+		t.Fatal("job started again while it was still running")
+	default:
+	}
+	status := s.Jobs()[0]
+	if status.Runs != 1 || status.SkippedOverlaps != 2 {
+		t.Errorf("runs = %d, skipped overlaps = %d; want 1, 2", status.Runs, status.SkippedOverlaps)
+	}
+	if status.Running {
+		t.Error("job still marked running after it finished")
+	}
+}
+
+func TestDispatchSkipsWhenNotLeader(t *testing.T) {
+	job, started, _ := blockingJob("follower", time.Minute)
+	s := newTestScheduler(t, job)
+	s.isLeader = func() bool { return false }
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.Local)
+
+	s.dispatchDue(context.Background(), now)
+	s.wg.Wait()
+
+	select {
+	case <-started:
+		t.Fatal("job ran on a replica that is not the leader")
+	default:
+	}
+	if next := s.Jobs()[0].NextRun; !next.After(now) {
+		t.Errorf("next run = %s, want after %s", next, now)
+	}
+}
+
+func TestRunOutcomes(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		run         func(ctx context.Context, scheduled time.Time) error
+		wantOutcome string
+		wantErr     string
+	}{
+		{
+			name:        "succeeded",
+			timeout:     time.Second,
+			run:         func(ctx context.Context, scheduled time.Time) error { return nil },
+			wantOutcome: OutcomeSucceeded,
+		},
+		{
+			name:    "panicked",
+			timeout: time.Second,
+			run: func(ctx context.Context, scheduled time.Time) error {
+				panic("boom")
+			},
+			wantOutcome: OutcomePanicked,
+			wantErr:     "panic: boom",
+		},
+		{
+			name:    "timed out",
+			timeout: 10 * time.Millisecond,
+			run: func(ctx context.Context, scheduled time.Time) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			wantOutcome: OutcomeTimedOut,
+			wantErr:     context.DeadlineExceeded.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScheduler(t, Job{Name: "job", Schedule: "@hourly", Timeout: tt.timeout, Run: tt.run})
+			scheduled := time.Date(2026, 10, 19, 8, 0, 0, 0, time.Local)
+			s.jobs[0].next = scheduled
+
+			s.dispatchDue(context.Background(), scheduled)
+			s.wg.Wait()
+
+			status := s.Jobs()[0]
+			if status.LastRun == nil {
+				t.Fatal("no run recorded")
+			}
+			if status.LastRun.Outcome != tt.wantOutcome || status.LastRun.Error != tt.wantErr {
+				t.Errorf("last run = %s %q, want %s %q", status.LastRun.Outcome, status.LastRun.Error, tt.wantOutcome, tt.wantErr)
+			}
+			wantFailures := 0
+			if tt.wantErr != "" {
+				wantFailures = 1
+			}
+			if status.Runs != 1 || status.Failures != wantFailures {
+				t.Errorf("runs = %d, failures = %d; want 1, %d", status.Runs, status.Failures, wantFailures)
+			}
+			if !status.LastRun.ScheduledAt.Equal(scheduled) {
+				t.Errorf("scheduled at = %s, want %s", status.LastRun.ScheduledAt, scheduled)
+			}
+		})
+	}
+}
+
+func TestRunWaitsForRunningJobsOnShutdown(t *testing.T) {
+	job, started, release := blockingJob("slow", time.Minute)
+	s := newTestScheduler(t, job)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.dispatchDue(ctx, time.Now())
+	waitStarted(t, started)
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(stopped)
+	}()
+	cancel()
+
+	select {
+	case <-stopped:
+		t.Fatal("Run returned while a job was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the running job finished")
+	}
+	if last := s.Jobs()[0].LastRun; last == nil || last.Outcome != OutcomeSucceeded {
+		t.Errorf("last run = %+v, want a succeeded run", last)
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/auth"
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+const (
+	APIScopeTimetableRead  = "timetable:read"
+	APIScopeOverridesWrite = "overrides:write"
+	APIScopeOutboxRead     = "outbox:read"
+)
+
+var knownAPIScopes = []string{APIScopeTimetableRead, APIScopeOverridesWrite, APIScopeOutboxRead}
+
+const (
+	apiKeyPrefix       = "ttk_"
+	apiKeyDisplayChars = 12
+	// apiKeyTouchInterval is how stale last_used_at may get before a request
+	// with the key refreshes it, so busy keys do not write on every request.
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyGrant is what an API key requester may do in place of roles.
+type APIKeyGrant struct {
+	Name     string
+	Scopes   []string
+	ClassIDs []uuid.UUID
+}
+
+// allows maps scopes onto capabilities. overrides:write is limited to the
+// key's classes and covers every course in them.
+func (g APIKeyGrant) allows(capability Capability, classID uuid.UUID) bool {
+	switch capability {
+	case CapEditOverrides:
+		return slices.Contains(g.Scopes, APIScopeOverridesWrite) && slices.Contains(g.ClassIDs, classID)
+	case CapReadOutbox:
+		return slices.Contains(g.Scopes, APIScopeOutboxRead)
+	default:
+		return false
+	}
+}
+
+// IssueAPIKey creates a key and returns it with its secret, which is not
+// stored and cannot be retrieved later.
+func (s *TimetableService) IssueAPIKey(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	name string,
+	scopes []string,
+	classIDs []uuid.UUID,
+	expiresAt *time.Time,
+) (domain.APIKey, string, error) {
+	var invalid ValidationError
+	if name == "" {
+		invalid.Add("name", "is required")
+	}
+	if len(scopes) == 0 {
+		invalid.Add("scopes", "must not be empty")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(knownAPIScopes, scope) {
+			invalid.Add("scopes", fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+	if slices.Contains(scopes, APIScopeOverridesWrite) && len(classIDs) == 0 {
+		invalid.Add("class_ids", "is required with scope "+APIScopeOverridesWrite)
+	}
+	if !slices.Contains(scopes, APIScopeOverridesWrite) && len(classIDs) > 0 {
+		invalid.Add("class_ids", "only applies to scope "+APIScopeOverridesWrite)
+	}
+	if expiresAt != nil && !expiresAt.After(s.clock()) {
+		invalid.Add("expires_at", "must be in the future")
+	}
+	if err := invalid.Err(); err != nil {
+		return domain.APIKey{}, "", err
+	}
+
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	if err := s.authorize(user, CapManageAPIKeys, Resource{}); err != nil {
+		return domain.APIKey{}, "", err
+	}
+
+	secret, err := newAPIKeySecret()
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	scopes = slices.Compact(slices.Sorted(slices.Values(scopes)))
+
+	// The secret must never reach the idempotency store, so issuing ignores
+	// Idempotency-Key.
+	var issued domain.APIKey
+	err = s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		created, err := repos.APIKeys.Insert(ctx, domain.APIKey{
+			ID:        uuid.New(),
+			Name:      name,
+			Prefix:    secret[:apiKeyDisplayChars],
+			Scopes:    scopes,
+			ClassIDs:  classIDs,
+			CreatedBy: requesterID,
+			ExpiresAt: expiresAt,
+		}, hashAPIKey(secret))
+		if err != nil {
+			return err
+		}
+		issued = created
+
+		return s.recordAudit(ctx, repos, requesterID, AuditAPIKeyIssue, uuid.Nil, nil, map[string]any{
+			"api_key_id": created.ID,
+			"name":       name,
+			"prefix":     created.Prefix,
+			"scopes":     scopes,
+			"class_ids":  classIDs,
+			"expires_at": expiresAt,
+		})
+	})
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	return issued, secret, nil
+}
+
+func (s *TimetableService) ListAPIKeys(ctx context.Context, requesterID uuid.UUID) ([]domain.APIKey, error) {
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(user, CapManageAPIKeys, Resource{}); err != nil {
+		return nil, err
+	}
+
+	var keys []domain.APIKey
+	err = s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		var err error
+		keys, err = repos.APIKeys.List(ctx)
+		return err
+	})
+	return keys, err
+}
+
+// RevokeAPIKey disables a key immediately. Revoking a missing or already
+// revoked key reports ErrNotFound.
+func (s *TimetableService) RevokeAPIKey(ctx context.Context, requesterID uuid.UUID, keyID uuid.UUID) error {
+	return s.idempotent(ctx, requesterID, nil, func(ctx context.Context) error {
+		return s.revokeAPIKey(ctx, requesterID, keyID)
+	})
+}
+
+func (s *TimetableService) revokeAPIKey(ctx context.Context, requesterID uuid.UUID, keyID uuid.UUID) error {
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(user, CapManageAPIKeys, Resource{}); err != nil {
+		return err
+	}
+
+	return s.withIdempotentTx(ctx, requesterID, nil, func(ctx context.Context, repos repository.TxRepositories) error {
+		revoked, err := repos.APIKeys.Revoke(ctx, keyID)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return ErrNotFound
+		}
+		return s.recordAudit(ctx, repos, requesterID, AuditAPIKeyRevoke, uuid.Nil, nil, map[string]any{
+			"api_key_id": keyID,
+		})
+	})
+}
+
+// ResolveAPIKey implements auth.APIKeyResolver.
+func (s *TimetableService) ResolveAPIKey(ctx context.Context, secret string) (auth.Principal, error) {
+	var key domain.APIKey
+	err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		var err error
+		key, err = repos.APIKeys.GetByHash(ctx, hashAPIKey(secret))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: unknown API key", auth.ErrUnauthenticated)
+			}
+			return err
+		}
+		if key.RevokedAt != nil {
+			return fmt.Errorf("%w: API key revoked", auth.ErrUnauthenticated)
+		}
+		if key.ExpiresAt != nil && !s.clock().Before(*key.ExpiresAt) {
+			return fmt.Errorf("%w: API key expired", auth.ErrUnauthenticated)
+		}
+		if key.LastUsedAt != nil && s.clock().Sub(*key.LastUsedAt) < apiKeyTouchInterval {
+			return nil
+		}
+		return repos.APIKeys.TouchLastUsed(ctx, key.ID)
+	})
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	return auth.Principal{
+		UserID: key.ID,
+		Source: auth.SourceAPIKey,
+		APIKey: &auth.APIKeyGrant{Name: key.Name, Scopes: key.Scopes, ClassIDs: key.ClassIDs},
+	}, nil
+}
+
+// apiKeyFromContext returns the grant of the request's API key when the
+// requester is one.
+func apiKeyFromContext(ctx context.Context, requesterID uuid.UUID) (*APIKeyGrant, bool) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || principal.Source != auth.SourceAPIKey || principal.APIKey == nil || principal.UserID != requesterID {
+		return nil, false
+	}
+	return &APIKeyGrant{
+		Name:     principal.APIKey.Name,
+		Scopes:   principal.APIKey.Scopes,
+		ClassIDs: principal.APIKey.ClassIDs,
+	}, true
+}
+
+// authorizeTimetableRead leaves reads open to every authenticated user, but
+// API keys need the timetable:read scope.
+func authorizeTimetableRead(ctx context.Context) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || principal.Source != auth.SourceAPIKey {
+		return nil
+	}
+	if principal.APIKey == nil || !slices.Contains(principal.APIKey.Scopes, APIScopeTimetableRead) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+func newAPIKeySecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashAPIKey(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
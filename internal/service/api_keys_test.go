@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/auth"
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+type fakeAPIKeys struct {
+	repository.APIKeyRepository
+	key     domain.APIKey
+	touched *int
+}
+
+func (f fakeAPIKeys) GetByHash(ctx context.Context, hash []byte) (domain.APIKey, error) {
+	return f.key, nil
+}
+
+func (f fakeAPIKeys) TouchLastUsed(ctx context.Context, id uuid.UUID) error {
+	*f.touched++
+	return nil
+}
+
+func TestResolveAPIKeyTouchesLastUsed(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name        string
+		lastUsedAt  *time.Time
+		revokedAt   *time.Time
+		wantTouched int
+		wantErr     error
+	}{
+		{name: "never used", wantTouched: 1},
+		{name: "used a while ago", lastUsedAt: ago(5 * time.Minute), wantTouched: 1},
+		{name: "used just now", lastUsedAt: ago(10 * time.Second), wantTouched: 0},
+		{name: "revoked", revokedAt: ago(time.Hour), wantTouched: 0, wantErr: auth.ErrUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var touched int
+			key := domain.APIKey{ID: uuid.New(), Name: "bot", Scopes: []string{APIScopeTimetableRead}, LastUsedAt: tt.lastUsedAt, RevokedAt: tt.revokedAt}
+			repos := repository.TxRepositories{APIKeys: fakeAPIKeys{key: key, touched: &touched}}
+			service := NewTimetableService(fakeTxManager{repos: repos}, fakeIdentity{}, DefaultPermissions())
+			service.clock = func() time.Time { return now }
+
+			principal, err := service.ResolveAPIKey(context.Background(), "ttk_secret")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveAPIKey error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && principal.UserID != key.ID {
+				t.Errorf("principal = %s, want %s", principal.UserID, key.ID)
+			}
+			if touched != tt.wantTouched {
+				t.Errorf("TouchLastUsed calls = %d, want %d", touched, tt.wantTouched)
+			}
+		})
+	}
+}
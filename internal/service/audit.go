@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+const (
+	AuditOverrideUpsert    = "override.upsert"
+	AuditDefaultSlotUpsert = "default_slot.upsert"
+	AuditDefaultSlotDelete = "default_slot.delete"
+	AuditSettingsUpdate    = "settings.update"
+	AuditDelegationGrant   = "delegation.grant"
+	AuditDelegationRevoke  = "delegation.revoke"
+	AuditDayLock           = "day.lock"
+	AuditDayUnlock         = "day.unlock"
+	AuditEditPolicyUpdate  = "edit_policy.update"
+	AuditAPIKeyIssue       = "api_key.issue"
+	AuditAPIKeyRevoke      = "api_key.revoke"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// recordAudit writes an audit entry in the same transaction as the change it
+// describes, so the log never claims a write that was rolled back. Changes
+// made with an API key are attributed to the key.
+func (s *TimetableService) recordAudit(
+	ctx context.Context,
+	repos repository.TxRepositories,
+	actorID uuid.UUID,
+	action string,
+	classID uuid.UUID,
+	date *time.Time,
+	details map[string]any,
+) error {
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	actorKind := domain.ActorUser
+	if _, ok := apiKeyFromContext(ctx, actorID); ok {
+		actorKind = domain.ActorAPIKey
+	}
+	return repos.Audit.Insert(ctx, domain.AuditEntry{
+		ID:        uuid.New(),
+		ActorID:   actorID,
+		ActorKind: actorKind,
+		Action:    action,
+		ClassID:   classID,
+		Date:      date,
+		Details:   encoded,
+	})
+}
+
+// ListAudit returns the most recent audit entries for a class, newest first,
+// or those not about any class when classID is uuid.Nil; only global roles
+// may read the latter. A limit of 0 selects the default.
+func (s *TimetableService) ListAudit(ctx context.Context, requesterID uuid.UUID, classID uuid.UUID, limit int) ([]domain.AuditEntry, error) {
+	if limit == 0 {
+		limit = defaultAuditLimit
+	}
+	if limit < 1 || limit > maxAuditLimit {
+		return nil, invalidField("limit", "must be between 1 and 500")
+	}
+
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(user, CapViewAudit, Resource{ClassID: classID}); err != nil {
+		return nil, err
+	}
+
+	var entries []domain.AuditEntry
+	err = s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		var err error
+		entries, err = repos.Audit.ListByClass(ctx, classID, limit)
+		return err
+	})
+	return entries, err
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// CircuitBreaker stops calls to a failing dependency. After threshold
+// consecutive failures it opens for cooldown, then lets a single trial call
+// through; the trial's outcome closes or reopens the breaker.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	clock     func() time.Time
+
+	mu            sync.Mutex
+	state         string
+	failures      int
+	openedAt      time.Time
+	trialInFlight bool
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		clock:     time.Now,
+		state:     BreakerClosed,
+	}
+}
+
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.clock().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.trialInFlight = true
+		return nil
+	case BreakerHalfOpen:
+		if b.trialInFlight {
+			return ErrCircuitOpen
+		}
+		b.trialInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *CircuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialInFlight = false
+	if success {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.clock()
+	}
+}
+
+// Abandon ends a call let through by Allow without an outcome, e.g. when the
+// caller cancelled it. A half-open breaker then lets the next trial through.
+func (b *CircuitBreaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trialInFlight = false
+}
+
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+var ErrLocked = errors.New("locked")
+
+// DayLockedError reports the lock that stopped an override.
+type DayLockedError struct {
+	Lock domain.DayLock
+}
+
+func (e *DayLockedError) Error() string {
+	if e.Lock.SlotIndex == 0 {
+		return fmt.Sprintf("%s is locked", e.Lock.Date.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("slot %d on %s is locked", e.Lock.SlotIndex, e.Lock.Date.Format("2006-01-02"))
+}
+
+func (e *DayLockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// checkDayLocks rejects an override of slotIndex when a lock covers it,
+// unless the requester could have set that lock themselves.
+func (s *TimetableService) checkDayLocks(
+	ctx context.Context,
+	repos repository.TxRepositories,
+	user IdentityUser,
+	resource Resource,
+	date time.Time,
+	slotIndex int,
+) error {
+	locks, err := repos.DayLocks.ListByDate(ctx, resource.ClassID, date)
+	if err != nil {
+		return err
+	}
+	for _, lock := range locks {
+		if !lock.Covers(slotIndex) {
+			continue
+		}
+		if s.permissions.Allows(user, CapLockDays, resource) {
+			return nil
+		}
+		return &DayLockedError{Lock: lock}
+	}
+	return nil
+}
+
+// LockDay locks a class's day, or one slot of it when slotIndex is positive,
+// and returns the new day version.
+func (s *TimetableService) LockDay(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	date time.Time,
+	slotIndex int,
+	reason string,
+) (int64, error) {
+	var dayVersion int64
+	err := s.idempotent(ctx, requesterID, &dayVersion, func(ctx context.Context) error {
+		var err error
+		dayVersion, err = s.lockDay(ctx, requesterID, classID, date, slotIndex, reason)
+		return err
+	})
+	return dayVersion, err
+}
+
+func (s *TimetableService) lockDay(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	date time.Time,
+	slotIndex int,
+	reason string,
+) (int64, error) {
+	localDate := truncateToDateLocal(date)
+	if slotIndex < 0 {
+		return 0, invalidField("slot_index", "must not be negative")
+	}
+	if localDate.Before(truncateToDateLocal(s.clock())) {
+		return 0, invalidField("date", "must not be in the past")
+	}
+
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return 0, err
+	}
+
+	var dayVersion int64
+	err = s.withIdempotentTx(ctx, requesterID, &dayVersion, func(ctx context.Context, repos repository.TxRepositories) error {
+		resource, err := s.lockResource(ctx, repos, classID, localDate, slotIndex)
+		if err != nil {
+			return err
+		}
+		if err := s.authorize(user, CapLockDays, resource); err != nil {
+			return err
+		}
+
+		if _, err := repos.DayLocks.Upsert(ctx, domain.DayLock{
+			ClassID:   classID,
+			Date:      localDate,
+			SlotIndex: slotIndex,
+			LockedBy:  requesterID,
+			Reason:    reason,
+		}); err != nil {
+			return err
+		}
+		if _, err = repos.DayVersions.Bump(ctx, classID, localDate); err != nil {
+			return err
+		}
+		if dayVersion, err = s.dayVersion(ctx, repos, classID, localDate); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, repos, requesterID, AuditDayLock, classID, &localDate, map[string]any{
+			"slot_index":  slotIndex,
+			"reason":      reason,
+			"day_version": dayVersion,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return dayVersion, nil
+}
+
+func (s *TimetableService) UnlockDay(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	date time.Time,
+	slotIndex int,
+) (int64, error) {
+	var dayVersion int64
+	err := s.idempotent(ctx, requesterID, &dayVersion, func(ctx context.Context) error {
+		var err error
+		dayVersion, err = s.unlockDay(ctx, requesterID, classID, date, slotIndex)
+		return err
+	})
+	return dayVersion, err
+}
+
+func (s *TimetableService) unlockDay(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	date time.Time,
+	slotIndex int,
+) (int64, error) {
+	localDate := truncateToDateLocal(date)
+	if slotIndex < 0 {
+		return 0, invalidField("slot_index", "must not be negative")
+	}
+
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return 0, err
+	}
+
+	var dayVersion int64
+	err = s.withIdempotentTx(ctx, requesterID, &dayVersion, func(ctx context.Context, repos repository.TxRepositories) error {
+		resource, err := s.lockResource(ctx, repos, classID, localDate, slotIndex)
+		if err != nil {
+			return err
+		}
+		if err := s.authorize(user, CapLockDays, resource); err != nil {
+			return err
+		}
+
+		deleted, err := repos.DayLocks.Delete(ctx, classID, localDate, slotIndex)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return ErrNotFound
+		}
+		if _, err = repos.DayVersions.Bump(ctx, classID, localDate); err != nil {
+			return err
+		}
+		if dayVersion, err = s.dayVersion(ctx, repos, classID, localDate); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, repos, requesterID, AuditDayUnlock, classID, &localDate, map[string]any{
+			"slot_index":  slotIndex,
+			"day_version": dayVersion,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return dayVersion, nil
+}
+
+// lockResource is the whole class for a day lock, and the slot's course for a
+// slot lock, so faculty can lock the slots of the courses they teach.
+func (s *TimetableService) lockResource(
+	ctx context.Context,
+	repos repository.TxRepositories,
+	classID uuid.UUID,
+	date time.Time,
+	slotIndex int,
+) (Resource, error) {
+	resource := Resource{ClassID: classID}
+	if slotIndex == 0 {
+		return resource, nil
+	}
+	day, err := s.resolveTimetableWithRepos(ctx, repos, classID, date)
+	if err != nil {
+		return Resource{}, err
+	}
+	for _, slot := range day {
+		if slot.SlotIndex == slotIndex {
+			resource.CourseCodes = append(resource.CourseCodes, slot.CourseCode)
+		}
+	}
+	return resource, nil
+}
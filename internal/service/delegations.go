@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+const maxDelegationDays = 31
+
+var ErrDelegationRevoked = fmt.Errorf("%w: delegation already revoked", ErrConflict)
+
+// authorizeOverride lets the requester edit overrides when their roles allow
+// it or when they hold an active delegation for the class on date.
+// Delegations cover every course of the class. API keys act only within
+// their own grant and never fall back to delegations.
+func (s *TimetableService) authorizeOverride(
+	ctx context.Context,
+	repos repository.TxRepositories,
+	user IdentityUser,
+	resource Resource,
+	date time.Time,
+) error {
+	err := s.authorize(user, CapEditOverrides, resource)
+	if err == nil || user.APIKey != nil {
+		return err
+	}
+	delegated, lookupErr := repos.Delegations.HasActive(ctx, user.ID, resource.ClassID, date)
+	if lookupErr != nil {
+		return lookupErr
+	}
+	if !delegated {
+		return err
+	}
+	return nil
+}
+
+// authorizeDelegation requires the delegate capability and edit rights over
+// the whole class, so nobody can hand out more than they hold themselves.
+func (s *TimetableService) authorizeDelegation(user IdentityUser, classID uuid.UUID) error {
+	resource := Resource{ClassID: classID}
+	if err := s.authorize(user, CapDelegate, resource); err != nil {
+		return err
+	}
+	return s.authorize(user, CapEditOverrides, resource)
+}
+
+func (s *TimetableService) GrantDelegation(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	delegateID uuid.UUID,
+	startsOn time.Time,
+	endsOn time.Time,
+) (domain.Delegation, error) {
+	var delegation domain.Delegation
+	err := s.idempotent(ctx, requesterID, &delegation, func(ctx context.Context) error {
+		var err error
+		delegation, err = s.grantDelegation(ctx, requesterID, classID, delegateID, startsOn, endsOn)
+		return err
+	})
+	return delegation, err
+}
+
+func (s *TimetableService) grantDelegation(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	delegateID uuid.UUID,
+	startsOn time.Time,
+	endsOn time.Time,
+) (domain.Delegation, error) {
+	startsOn = truncateToDateLocal(startsOn)
+	endsOn = truncateToDateLocal(endsOn)
+	today := truncateToDateLocal(s.clock())
+
+	var invalid ValidationError
+	if delegateID == requesterID {
+		invalid.Add("delegate_id", "must not be the requester")
+	}
+	if endsOn.Before(startsOn) {
+		invalid.Add("ends_on", "must not be before starts_on")
+	} else if endsOn.Before(today) {
+		invalid.Add("ends_on", "must not be in the past")
+	} else if endsOn.Sub(startsOn) >= maxDelegationDays*24*time.Hour {
+		invalid.Add("ends_on", fmt.Sprintf("must be within %d days of starts_on", maxDelegationDays))
+	}
+	if err := invalid.Err(); err != nil {
+		return domain.Delegation{}, err
+	}
+
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return domain.Delegation{}, err
+	}
+	if err := s.authorizeDelegation(user, classID); err != nil {
+		return domain.Delegation{}, err
+	}
+
+	var delegation domain.Delegation
+	err = s.withIdempotentTx(ctx, requesterID, &delegation, func(ctx context.Context, repos repository.TxRepositories) error {
+		created, err := repos.Delegations.Insert(ctx, domain.Delegation{
+			ID:         uuid.New(),
+			ClassID:    classID,
+			DelegateID: delegateID,
+			GrantedBy:  requesterID,
+			StartsOn:   startsOn,
+			EndsOn:     endsOn,
+		})
+		if err != nil {
+			return err
+		}
+		delegation = created
+
+		return s.recordAudit(ctx, repos, requesterID, AuditDelegationGrant, classID, nil, map[string]any{
+			"delegation_id": created.ID,
+			"delegate_id":   delegateID,
+			"starts_on":     startsOn.Format("2006-01-02"),
+			"ends_on":       endsOn.Format("2006-01-02"),
+		})
+	})
+	if err != nil {
+		return domain.Delegation{}, err
+	}
+	return delegation, nil
+}
+
+// RevokeDelegation ends a delegation immediately. The grantor may always
+// revoke it; anyone else needs the same rights that granting would.
+func (s *TimetableService) RevokeDelegation(ctx context.Context, requesterID uuid.UUID, delegationID uuid.UUID) error {
+	return s.idempotent(ctx, requesterID, nil, func(ctx context.Context) error {
+		return s.revokeDelegation(ctx, requesterID, delegationID)
+	})
+}
+
+func (s *TimetableService) revokeDelegation(ctx context.Context, requesterID uuid.UUID, delegationID uuid.UUID) error {
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return err
+	}
+
+	return s.withIdempotentTx(ctx, requesterID, nil, func(ctx context.Context, repos repository.TxRepositories) error {
+		delegation, err := repos.Delegations.Get(ctx, delegationID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if delegation.GrantedBy != requesterID {
+			if err := s.authorizeDelegation(user, delegation.ClassID); err != nil {
+				return err
+			}
+		}
+
+		revoked, err := repos.Delegations.Revoke(ctx, delegationID, requesterID)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return ErrDelegationRevoked
+		}
+
+		return s.recordAudit(ctx, repos, requesterID, AuditDelegationRevoke, delegation.ClassID, nil, map[string]any{
+			"delegation_id": delegation.ID,
+			"delegate_id":   delegation.DelegateID,
+			"starts_on":     delegation.StartsOn.Format("2006-01-02"),
+			"ends_on":       delegation.EndsOn.Format("2006-01-02"),
+		})
+	})
+}
+
+// ListDelegations returns the class's delegations that are active or still
+// to come.
+func (s *TimetableService) ListDelegations(ctx context.Context, requesterID uuid.UUID, classID uuid.UUID) ([]domain.Delegation, error) {
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeDelegation(user, classID); err != nil {
+		return nil, err
+	}
+
+	var delegations []domain.Delegation
+	err = s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		var err error
+		delegations, err = repos.Delegations.ListCurrentByClass(ctx, classID, truncateToDateLocal(s.clock()))
+		return err
+	})
+	return delegations, err
+}
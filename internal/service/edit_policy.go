@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+var ErrEditCutoff = errors.New("edit cutoff passed")
+
+// EditCutoffError reports a slot whose edit window has closed.
+type EditCutoffError struct {
+	SlotIndex     int
+	SlotStart     time.Time
+	CutoffAt      time.Time
+	CutoffMinutes int
+	// BypassAllowed is set when the requester may bypass the cutoff by
+	// giving a reason.
+	BypassAllowed bool
+}
+
+func (e *EditCutoffError) Error() string {
+	message := fmt.Sprintf(
+		"slot %d starting %s can no longer be changed after %s",
+		e.SlotIndex,
+		e.SlotStart.Format("2006-01-02 15:04"),
+		e.CutoffAt.Format("15:04"),
+	)
+	if e.BypassAllowed {
+		message += "; give bypass_reason to change it anyway"
+	}
+	return message
+}
+
+func (e *EditCutoffError) Is(target error) bool {
+	return target == ErrEditCutoff
+}
+
+// checkEditCutoff applies the class's cutoff to the slot being overridden,
+// judged by its start before the change and by the new start when the
+// override sets one, so a slot can neither be changed once its window has
+// closed nor be moved into a closed window or the past. Requesters holding
+// bypass-cutoff pass by giving a reason; bypassed reports whether they had to.
+func (s *TimetableService) checkEditCutoff(
+	ctx context.Context,
+	repos repository.TxRepositories,
+	user IdentityUser,
+	resource Resource,
+	date time.Time,
+	slotIndex int,
+	day []domain.Slot,
+	newStart *time.Time,
+	bypassReason string,
+) (bool, error) {
+	policy, err := repos.EditPolicies.Get(ctx, resource.ClassID)
+	if err != nil {
+		return false, err
+	}
+	if policy.CutoffMinutes == nil {
+		return false, nil
+	}
+
+	starts := make([]time.Time, 0, 2)
+	for _, slot := range day {
+		if slot.SlotIndex == slotIndex && !slot.StartTime.IsZero() {
+			starts = append(starts, slot.StartTime)
+		}
+	}
+	if newStart != nil {
+		starts = append(starts, *newStart)
+	}
+
+	for _, start := range starts {
+		slotStart := time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), 0, 0, time.Local)
+		cutoffAt := slotStart.Add(-time.Duration(*policy.CutoffMinutes) * time.Minute)
+		if s.clock().Before(cutoffAt) {
+			continue
+		}
+
+		bypassAllowed := s.permissions.Allows(user, CapBypassCutoff, resource)
+		if bypassAllowed && bypassReason != "" {
+			return true, nil
+		}
+		return false, &EditCutoffError{
+			SlotIndex:     slotIndex,
+			SlotStart:     slotStart,
+			CutoffAt:      cutoffAt,
+			CutoffMinutes: *policy.CutoffMinutes,
+			BypassAllowed: bypassAllowed,
+		}
+	}
+	return false, nil
+}
+
+func (s *TimetableService) GetEditPolicy(ctx context.Context, requesterID uuid.UUID, classID uuid.UUID) (domain.EditPolicy, error) {
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return domain.EditPolicy{}, err
+	}
+	if err := s.authorize(user, CapManageSettings, Resource{ClassID: classID}); err != nil {
+		return domain.EditPolicy{}, err
+	}
+
+	var policy domain.EditPolicy
+	err = s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		var err error
+		policy, err = repos.EditPolicies.Get(ctx, classID)
+		return err
+	})
+	return policy, err
+}
+
+// UpdateEditPolicy sets the class's cutoff; nil removes it.
+func (s *TimetableService) UpdateEditPolicy(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	cutoffMinutes *int,
+) (domain.EditPolicy, error) {
+	var policy domain.EditPolicy
+	err := s.idempotent(ctx, requesterID, &policy, func(ctx context.Context) error {
+		var err error
+		policy, err = s.updateEditPolicy(ctx, requesterID, classID, cutoffMinutes)
+		return err
+	})
+	return policy, err
+}
+
+func (s *TimetableService) updateEditPolicy(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	cutoffMinutes *int,
+) (domain.EditPolicy, error) {
+	if cutoffMinutes != nil && (*cutoffMinutes < 0 || *cutoffMinutes > 24*60) {
+		return domain.EditPolicy{}, invalidField("cutoff_minutes", "must be between 0 and 1440")
+	}
+
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return domain.EditPolicy{}, err
+	}
+	if err := s.authorize(user, CapManageSettings, Resource{ClassID: classID}); err != nil {
+		return domain.EditPolicy{}, err
+	}
+
+	var policy domain.EditPolicy
+	err = s.withIdempotentTx(ctx, requesterID, &policy, func(ctx context.Context, repos repository.TxRepositories) error {
+		stored, err := repos.EditPolicies.Upsert(ctx, domain.EditPolicy{ClassID: classID, CutoffMinutes: cutoffMinutes})
+		if err != nil {
+			return err
+		}
+		policy = stored
+
+		return s.recordAudit(ctx, repos, requesterID, AuditEditPolicyUpdate, classID, nil, map[string]any{
+			"cutoff_minutes": cutoffMinutes,
+		})
+	})
+	if err != nil {
+		return domain.EditPolicy{}, err
+	}
+	return policy, nil
+}
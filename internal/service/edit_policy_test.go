@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+type fakeEditPolicies struct {
+	repository.EditPolicyRepository
+	cutoffMinutes *int
+}
+
+func (f fakeEditPolicies) Get(ctx context.Context, classID uuid.UUID) (domain.EditPolicy, error) {
+	return domain.EditPolicy{ClassID: classID, CutoffMinutes: f.cutoffMinutes}, nil
+}
+
+func TestCheckEditCutoff(t *testing.T) {
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	at := func(hour, minute int) time.Time {
+		return time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+	startAt := func(hour, minute int) *time.Time {
+		start := at(hour, minute)
+		return &start
+	}
+	dayWithSlot := func(hour, minute int) []domain.Slot {
+		return []domain.Slot{{SlotIndex: 1, CourseCode: "CS101", StartTime: at(hour, minute), EndTime: at(hour+1, minute)}}
+	}
+	cr := userWith(role("cr", &classA, ""))
+	faculty := userWith(role("faculty", &classA, ""))
+	resource := Resource{ClassID: classA, CourseCodes: []string{"CS101"}}
+
+	tests := []struct {
+		name         string
+		user         IdentityUser
+		day          []domain.Slot
+		newStart     *time.Time
+		bypassReason string
+		wantBypassed bool
+		wantStart    *time.Time
+	}{
+		{name: "open slot kept in place", user: cr, day: dayWithSlot(10, 0)},
+		{name: "open slot moved later", user: cr, day: dayWithSlot(10, 0), newStart: startAt(11, 0)},
+		{name: "open slot moved into the window", user: cr, day: dayWithSlot(10, 0), newStart: startAt(8, 15), wantStart: startAt(8, 15)},
+		{name: "open slot moved into the past", user: cr, day: dayWithSlot(10, 0), newStart: startAt(7, 0), wantStart: startAt(7, 0)},
+		{name: "closed slot moved later", user: cr, day: dayWithSlot(8, 20), newStart: startAt(11, 0), wantStart: startAt(8, 20)},
+		{name: "new slot outside the window", user: cr, newStart: startAt(9, 0)},
+		{name: "new slot in the window", user: cr, newStart: startAt(8, 10), wantStart: startAt(8, 10)},
+		{name: "cr giving a reason", user: cr, day: dayWithSlot(10, 0), newStart: startAt(8, 15), bypassReason: "exam", wantStart: startAt(8, 15)},
+		{name: "faculty giving a reason", user: faculty, day: dayWithSlot(10, 0), newStart: startAt(8, 15), bypassReason: "exam", wantBypassed: true},
+	}
+
+	cutoff := 30
+	repos := repository.TxRepositories{EditPolicies: fakeEditPolicies{cutoffMinutes: &cutoff}}
+	service := newPermissionTestService(fakeIdentity{}, fakeDelegations{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bypassed, err := service.checkEditCutoff(context.Background(), repos, tt.user, resource, date, 1, tt.day, tt.newStart, tt.bypassReason)
+			if bypassed != tt.wantBypassed {
+				t.Errorf("bypassed = %v, want %v", bypassed, tt.wantBypassed)
+			}
+			if tt.wantStart == nil {
+				if err != nil {
+					t.Fatalf("checkEditCutoff: %v", err)
+				}
+				return
+			}
+			var cutoffErr *EditCutoffError
+			if !errors.As(err, &cutoffErr) {
+				t.Fatalf("error = %v, want an EditCutoffError", err)
+			}
+			if cutoffErr.SlotStart.Hour() != tt.wantStart.Hour() || cutoffErr.SlotStart.Minute() != tt.wantStart.Minute() {
+				t.Errorf("slot start = %s, want %s", cutoffErr.SlotStart.Format("15:04"), tt.wantStart.Format("15:04"))
+			}
+		})
+	}
+}
+
+func TestCheckEditCutoffWithoutPolicy(t *testing.T) {
+	service := newPermissionTestService(fakeIdentity{}, fakeDelegations{})
+	repos := repository.TxRepositories{EditPolicies: fakeEditPolicies{}}
+	start := time.Date(0, 1, 1, 7, 0, 0, 0, time.UTC)
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+	bypassed, err := service.checkEditCutoff(context.Background(), repos, userWith(), Resource{ClassID: classA}, date, 1, nil, &start, "")
+	if err != nil || bypassed {
+		t.Fatalf("checkEditCutoff = %v, %v; want false, nil", bypassed, err)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+
+	ErrVersionMismatch = fmt.Errorf("%w: version mismatch", ErrConflict)
+)
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError reports invalid input per field. It matches ErrInvalidInput
+// so callers that only care about the category keep working.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Add(field string, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns e when at least one field was reported, and nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		parts = append(parts, field.Field+" "+field.Message)
+	}
+	return "invalid input: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+func invalidField(field string, message string) error {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
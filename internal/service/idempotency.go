@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+const idempotencyKeyTTL = 24 * time.Hour
+
+// idempotencyClaimLease is how long a claimed key without an outcome blocks
+// its retries. Requests finish long before; a claim this old belongs to a
+// request that died between claiming the key and storing its outcome.
+const idempotencyClaimLease = 5 * time.Minute
+
+var (
+	ErrIdempotencyKeyReused   = fmt.Errorf("%w: idempotency key reused for a different request", ErrInvalidInput)
+	ErrIdempotencyKeyInFlight = fmt.Errorf("%w: a request with this idempotency key is still in progress", ErrConflict)
+)
+
+type IdempotencyKey struct {
+	Key         string
+	Fingerprint string
+}
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey attaches a client supplied idempotency key to ctx. Write
+// methods that find a key replay the stored outcome for repeated requests.
+func WithIdempotencyKey(ctx context.Context, key IdempotencyKey) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+func idempotencyKeyFromContext(ctx context.Context) (IdempotencyKey, bool) {
+	key, ok := ctx.Value(idempotencyKeyContextKey{}).(IdempotencyKey)
+	return key, ok && key.Key != ""
+}
+
+// idempotencyClaim is a key claimed by the running request. completed is set
+// once withIdempotentTx stored the result alongside the write.
+type idempotencyClaim struct {
+	key       IdempotencyKey
+	completed bool
+}
+
+type idempotencyClaimContextKey struct{}
+
+// idempotent runs a keyed write method. The key is looked up before run does
+// any work, so a repeated request replays the stored outcome, result or
+// error, without looking up the requester or validating input again. A key
+// still held by a request in flight fails with ErrIdempotencyKeyInFlight.
+//
+// Otherwise the key is claimed and run's outcome is stored: a result by
+// withIdempotentTx in the same transaction as the write, an error once run
+// returns. Errors that say nothing about the request itself, such as an
+// unavailable database, release the key so that a retry runs again.
+func (s *TimetableService) idempotent(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	result any,
+	run func(ctx context.Context) error,
+) error {
+	key, ok := idempotencyKeyFromContext(ctx)
+	if !ok {
+		return run(ctx)
+	}
+
+	var record *domain.IdempotencyRecord
+	err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		claimed, err := repos.Idempotency.Claim(ctx, requesterID, key.Key, key.Fingerprint, idempotencyKeyTTL, idempotencyClaimLease)
+		if err != nil || claimed {
+			return err
+		}
+		existing, err := repos.Idempotency.Get(ctx, requesterID, key.Key)
+		if err != nil {
+			return err
+		}
+		record = &existing
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if record != nil {
+		return replayIdempotent(*record, key, result)
+	}
+
+	claim := &idempotencyClaim{key: key}
+	runErr := run(context.WithValue(ctx, idempotencyClaimContextKey{}, claim))
+	if runErr == nil && claim.completed {
+		return nil
+	}
+
+	// The outcome is stored even when the request was cancelled meanwhile.
+	err = s.txManager.WithTx(context.WithoutCancel(ctx), func(ctx context.Context, repos repository.TxRepositories) error {
+		if runErr == nil {
+			response, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			return repos.Idempotency.Complete(ctx, requesterID, key.Key, response)
+		}
+		if outcome, ok := encodeStoredError(runErr); ok {
+			return repos.Idempotency.Fail(ctx, requesterID, key.Key, outcome)
+		}
+		return repos.Idempotency.Release(ctx, requesterID, key.Key)
+	})
+	if err != nil {
+		// The claim lease still frees the key eventually.
+		slog.ErrorContext(ctx, "storing idempotency outcome failed", "error", err)
+	}
+	return runErr
+}
+
+func replayIdempotent(record domain.IdempotencyRecord, key IdempotencyKey, result any) error {
+	if record.Fingerprint != key.Fingerprint {
+		return ErrIdempotencyKeyReused
+	}
+	switch {
+	case len(record.Error) > 0:
+		var stored storedError
+		if err := json.Unmarshal(record.Error, &stored); err != nil {
+			return fmt.Errorf("decode stored idempotency error: %w", err)
+		}
+		return stored.err()
+	case len(record.Response) > 0:
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(record.Response, result)
+	default:
+		return ErrIdempotencyKeyInFlight
+	}
+}
+
+// withIdempotentTx runs fn in a transaction. When the request claimed an
+// idempotency key through idempotent, result is stored for replays in the
+// same transaction, so a rolled back write leaves no result behind.
+func (s *TimetableService) withIdempotentTx(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	result any,
+	fn func(ctx context.Context, repos repository.TxRepositories) error,
+) error {
+	claim, ok := ctx.Value(idempotencyClaimContextKey{}).(*idempotencyClaim)
+	if !ok {
+		return s.txManager.WithTx(ctx, fn)
+	}
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		if err := fn(ctx, repos); err != nil {
+			return err
+		}
+		response, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		return repos.Idempotency.Complete(ctx, requesterID, claim.key.Key, response)
+	})
+	if err == nil {
+		claim.completed = true
+	}
+	return err
+}
+
+// storedError is a failed request's error as stored for its replays. Errors
+// the API reports with details keep them; the others are stored by kind.
+type storedError struct {
+	Kind         string             `json:"kind"`
+	Fields       []FieldError       `json:"fields,omitempty"`
+	SlotConflict *SlotConflictError `json:"slot_conflict,omitempty"`
+	DayLocked    *DayLockedError    `json:"day_locked,omitempty"`
+	EditCutoff   *EditCutoffError   `json:"edit_cutoff,omitempty"`
+}
+
+// replayableErrors are the errors stored by kind, most specific first.
+var replayableErrors = []struct {
+	kind string
+	err  error
+}{
+	{"version_mismatch", ErrVersionMismatch},
+	{"delegation_revoked", ErrDelegationRevoked},
+	{"conflict", ErrConflict},
+	{"locked", ErrLocked},
+	{"edit_cutoff", ErrEditCutoff},
+	{"invalid_input", ErrInvalidInput},
+	{"unauthorized", ErrUnauthorized},
+	{"not_found", ErrNotFound},
+}
+
+// encodeStoredError encodes err for replays. It reports false for errors
+// that are not an answer to the request, which are not worth replaying.
+func encodeStoredError(err error) (json.RawMessage, bool) {
+	var stored storedError
+	var validationErr *ValidationError
+	var conflictErr *SlotConflictError
+	var lockedErr *DayLockedError
+	var cutoffErr *EditCutoffError
+	switch {
+	case errors.As(err, &validationErr):
+		stored = storedError{Kind: "validation", Fields: validationErr.Fields}
+	case errors.As(err, &conflictErr):
+		stored = storedError{Kind: "slot_conflict", SlotConflict: conflictErr}
+	case errors.As(err, &lockedErr):
+		stored = storedError{Kind: "day_locked", DayLocked: lockedErr}
+	case errors.As(err, &cutoffErr):
+		stored = storedError{Kind: "edit_cutoff", EditCutoff: cutoffErr}
+	default:
+		for _, replayable := range replayableErrors {
+			if errors.Is(err, replayable.err) {
+				stored = storedError{Kind: replayable.kind}
+				break
+			}
+		}
+	}
+	if stored.Kind == "" {
+		return nil, false
+	}
+	encoded, encodeErr := json.Marshal(stored)
+	return encoded, encodeErr == nil
+}
+
+func (e storedError) err() error {
+	switch e.Kind {
+	case "validation":
+		return &ValidationError{Fields: e.Fields}
+	case "slot_conflict":
+		if e.SlotConflict != nil {
+			return e.SlotConflict
+		}
+	case "day_locked":
+		if e.DayLocked != nil {
+			return e.DayLocked
+		}
+	case "edit_cutoff":
+		if e.EditCutoff != nil {
+			return e.EditCutoff
+		}
+	}
+	for _, replayable := range replayableErrors {
+		if replayable.kind == e.Kind {
+			return replayable.err
+		}
+	}
+	return fmt.Errorf("unknown stored idempotency error %q", e.Kind)
+}
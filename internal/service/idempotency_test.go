@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+// fakeIdempotency keeps records in memory. Like the Postgres repository, a
+// claim of a key that already has a record fails; expiry is not modelled.
+type fakeIdempotency struct {
+	repository.IdempotencyRepository
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+}
+
+func newFakeIdempotency() *fakeIdempotency {
+	return &fakeIdempotency{records: make(map[string]domain.IdempotencyRecord)}
+}
+
+func (f *fakeIdempotency) Claim(ctx context.Context, requesterID uuid.UUID, key string, fingerprint string, ttl time.Duration, lease time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := requesterID.String() + "/" + key
+	if _, ok := f.records[id]; ok {
+		return false, nil
+	}
+	f.records[id] = domain.IdempotencyRecord{RequesterID: requesterID, Key: key, Fingerprint: fingerprint}
+	return true, nil
+}
+
+func (f *fakeIdempotency) Get(ctx context.Context, requesterID uuid.UUID, key string) (domain.IdempotencyRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.records[requesterID.String()+"/"+key], nil
+}
+
+func (f *fakeIdempotency) Complete(ctx context.Context, requesterID uuid.UUID, key string, response json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := requesterID.String() + "/" + key
+	record := f.records[id]
+	record.Response = response
+	f.records[id] = record
+	return nil
+}
+
+func (f *fakeIdempotency) Fail(ctx context.Context, requesterID uuid.UUID, key string, outcome json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := requesterID.String() + "/" + key
+	record := f.records[id]
+	record.Error = outcome
+	f.records[id] = record
+	return nil
+}
+
+func (f *fakeIdempotency) Release(ctx context.Context, requesterID uuid.UUID, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.records, requesterID.String()+"/"+key)
+	return nil
+}
+
+func newIdempotencyTestService(users fakeIdentity) (*TimetableService, *fakeIdempotency) {
+	idempotency := newFakeIdempotency()
+	repos := repository.TxRepositories{Idempotency: idempotency}
+	return NewTimetableService(fakeTxManager{repos: repos}, users, DefaultPermissions()), idempotency
+}
+
+// writeVersion returns a run function that counts its calls and stores
+// version through withIdempotentTx, or fails with err.
+func writeVersion(service *TimetableService, requesterID uuid.UUID, result *int64, version int64, err error, calls *int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*calls++
+		if err != nil {
+			return err
+		}
+		return service.withIdempotentTx(ctx, requesterID, result, func(ctx context.Context, repos repository.TxRepositories) error {
+			*result = version
+			return nil
+		})
+	}
+}
+
+func TestIdempotentReplaysStoredOutcome(t *testing.T) {
+	requesterID := uuid.New()
+	keyed := WithIdempotencyKey(context.Background(), IdempotencyKey{Key: "retry-1", Fingerprint: "POST /a"})
+	invalid := invalidField("cutoff_minutes", "must be between 0 and 1440")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantVersion int64
+		wantErr     error
+		wantCalls   int
+	}{
+		{name: "success is replayed", wantVersion: 7, wantCalls: 1},
+		{name: "validation error is replayed", err: invalid, wantErr: ErrInvalidInput, wantCalls: 1},
+		{name: "version mismatch is replayed", err: ErrVersionMismatch, wantErr: ErrVersionMismatch, wantCalls: 1},
+		{name: "unauthorized is replayed", err: ErrUnauthorized, wantErr: ErrUnauthorized, wantCalls: 1},
+		{name: "internal error releases the key", err: errors.New("connection reset"), wantCalls: 2, wantVersion: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newIdempotencyTestService(fakeIdentity{})
+			var calls int
+
+			var first int64
+			err := service.idempotent(keyed, requesterID, &first, writeVersion(service, requesterID, &first, 7, tt.err, &calls))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("first call error = %v, want %v", err, tt.err)
+			}
+
+			var replayed int64
+			err = service.idempotent(keyed, requesterID, &replayed, writeVersion(service, requesterID, &replayed, 7, nil, &calls))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("replay error = %v, want %v", err, tt.wantErr)
+			}
+			if replayed != tt.wantVersion {
+				t.Errorf("replayed version = %d, want %d", replayed, tt.wantVersion)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("run calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotentReplaysValidationDetails(t *testing.T) {
+	service, _ := newIdempotencyTestService(fakeIdentity{})
+	requesterID := uuid.New()
+	keyed := WithIdempotencyKey(context.Background(), IdempotencyKey{Key: "retry-1", Fingerprint: "PUT /a"})
+	invalid := &ValidationError{Fields: []FieldError{{Field: "venue", Message: "is required"}}}
+
+	_ = service.idempotent(keyed, requesterID, nil, func(ctx context.Context) error { return invalid })
+	err := service.idempotent(keyed, requesterID, nil, func(ctx context.Context) error { return nil })
+
+	var replayed *ValidationError
+	if !errors.As(err, &replayed) {
+		t.Fatalf("replay error = %v, want a ValidationError", err)
+	}
+	if len(replayed.Fields) != 1 || replayed.Fields[0] != invalid.Fields[0] {
+		t.Errorf("replayed fields = %+v, want %+v", replayed.Fields, invalid.Fields)
+	}
+}
+
+func TestIdempotentRejectsReusedKey(t *testing.T) {
+	service, _ := newIdempotencyTestService(fakeIdentity{})
+	requesterID := uuid.New()
+	var calls int
+
+	var version int64
+	first := WithIdempotencyKey(context.Background(), IdempotencyKey{Key: "retry-1", Fingerprint: "POST /a"})
+	if err := service.idempotent(first, requesterID, &version, writeVersion(service, requesterID, &version, 7, nil, &calls)); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	other := WithIdempotencyKey(context.Background(), IdempotencyKey{Key: "retry-1", Fingerprint: "POST /b"})
+	err := service.idempotent(other, requesterID, &version, writeVersion(service, requesterID, &version, 8, nil, &calls))
+	if !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("error = %v, want %v", err, ErrIdempotencyKeyReused)
+	}
+
+	otherRequester := uuid.New()
+	if err := service.idempotent(first, otherRequester, &version, writeVersion(service, otherRequester, &version, 9, nil, &calls)); err != nil {
+		t.Fatalf("same key of another requester: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("run calls = %d, want 2", calls)
+	}
+}
+
+func TestIdempotentConcurrentDuplicate(t *testing.T) {
+	service, _ := newIdempotencyTestService(fakeIdentity{})
+	requesterID := uuid.New()
+	keyed := WithIdempotencyKey(context.Background(), IdempotencyKey{Key: "retry-1", Fingerprint: "POST /a"})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	firstDone := make(chan error, 1)
+	var first int64
+	go func() {
+		firstDone <- service.idempotent(keyed, requesterID, &first, func(ctx context.Context) error {
+			close(started)
+			<-release
+			return service.withIdempotentTx(ctx, requesterID, &first, func(ctx context.Context, repos repository.TxRepositories) error {
+				first = 7
+				return nil
+			})
+		})
+	}()
+	<-started
+
+	var calls int
+	var duplicate int64
+	err := service.idempotent(keyed, requesterID, &duplicate, writeVersion(service, requesterID, &duplicate, 8, nil, &calls))
+	if !errors.Is(err, ErrIdempotencyKeyInFlight) {
+		t.Fatalf("duplicate error = %v, want %v", err, ErrIdempotencyKeyInFlight)
+	}
+	if calls != 0 {
+		t.Errorf("duplicate ran %d times, want 0", calls)
+	}
+
+	close(release)
+	if err := <-firstDone; err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	err = service.idempotent(keyed, requesterID, &duplicate, writeVersion(service, requesterID, &duplicate, 8, nil, &calls))
+	if err != nil || duplicate != 7 || calls != 0 {
+		t.Errorf("retry after completion = %d, %v with %d runs; want 7, nil with 0 runs", duplicate, err, calls)
+	}
+}
+
+// countingIdentity counts requester lookups.
+type countingIdentity struct {
+	fakeIdentity
+	lookups *int
+}
+
+func (c countingIdentity) GetMe(ctx context.Context, userID uuid.UUID) (IdentityUser, error) {
+	*c.lookups++
+	return c.fakeIdentity.GetMe(ctx, userID)
+}
+
+func TestReplayedWriteSkipsRequesterLookup(t *testing.T) {
+	crClassA := userWith(role("cr", &classA, ""))
+	var lookups int
+	idempotency := newFakeIdempotency()
+	repos := repository.TxRepositories{Idempotency: idempotency}
+	identity := countingIdentity{fakeIdentity: fakeIdentity{crClassA.ID: crClassA}, lookups: &lookups}
+	service := NewTimetableService(fakeTxManager{repos: repos}, identity, DefaultPermissions())
+	keyed := WithIdempotencyKey(context.Background(), IdempotencyKey{Key: "retry-1", Fingerprint: "PUT /edit-policy"})
+	minutes := 30
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		_, err := service.UpdateEditPolicy(keyed, crClassA.ID, classA, &minutes)
+		if !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("attempt %d error = %v, want %v", attempt, err, ErrUnauthorized)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("requester lookups = %d, want 1", lookups)
+	}
+}
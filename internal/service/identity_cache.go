@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CachedIdentityClient keeps identity lookups for ttl and remembers unknown
+// users for negativeTTL, so that short identity outages and bursts of writes
+// from the same requester do not each need a round trip.
+type CachedIdentityClient struct {
+	next        IdentityClient
+	ttl         time.Duration
+	negativeTTL time.Duration
+	maxEntries  int
+	clock       func() time.Time
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]identityCacheEntry
+
+	hits           atomic.Uint64
+	negativeHits   atomic.Uint64
+	misses         atomic.Uint64
+	breakerStateFn func() string
+}
+
+type identityCacheEntry struct {
+	user      IdentityUser
+	notFound  bool
+	expiresAt time.Time
+}
+
+type IdentityCacheStats struct {
+	Hits         uint64
+	NegativeHits uint64
+	Misses       uint64
+	Entries      int
+	BreakerState string
+}
+
+func NewCachedIdentityClient(next IdentityClient, ttl time.Duration, negativeTTL time.Duration, maxEntries int) *CachedIdentityClient {
+	client := &CachedIdentityClient{
+		next:        next,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		maxEntries:  maxEntries,
+		clock:       time.Now,
+		entries:     make(map[uuid.UUID]identityCacheEntry),
+	}
+	if withBreaker, ok := next.(interface{ BreakerState() string }); ok {
+		client.breakerStateFn = withBreaker.BreakerState
+	}
+	return client
+}
+
+func (c *CachedIdentityClient) GetMe(ctx context.Context, userID uuid.UUID) (IdentityUser, error) {
+	if entry, ok := c.lookup(userID); ok {
+		if entry.notFound {
+			c.negativeHits.Add(1)
+			return IdentityUser{}, ErrNotFound
+		}
+		c.hits.Add(1)
+		return entry.user, nil
+	}
+	c.misses.Add(1)
+
+	user, err := c.next.GetMe(ctx, userID)
+	switch {
+	case err == nil:
+		c.store(userID, identityCacheEntry{user: user, expiresAt: c.clock().Add(c.ttl)})
+	case errors.Is(err, ErrNotFound) && c.negativeTTL > 0:
+		c.store(userID, identityCacheEntry{notFound: true, expiresAt: c.clock().Add(c.negativeTTL)})
+	}
+	return user, err
+}
+
+func (c *CachedIdentityClient) Stats() IdentityCacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	stats := IdentityCacheStats{
+		Hits:         c.hits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		Misses:       c.misses.Load(),
+		Entries:      entries,
+	}
+	if c.breakerStateFn != nil {
+		stats.BreakerState = c.breakerStateFn()
+	}
+	return stats
+}
+
+func (c *CachedIdentityClient) lookup(userID uuid.UUID) (identityCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[userID]
+	if !ok {
+		return identityCacheEntry{}, false
+	}
+	if !c.clock().Before(entry.expiresAt) {
+		delete(c.entries, userID)
+		return identityCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *CachedIdentityClient) store(userID uuid.UUID, entry identityCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evictLocked()
+	}
+	c.entries[userID] = entry
+}
+
+// evictLocked drops expired entries, and if the cache is still full, an
+// arbitrary live one; map iteration order is random enough for this.
+func (c *CachedIdentityClient) evictLocked() {
+	now := c.clock()
+	for userID, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, userID)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	for userID := range c.entries {
+		delete(c.entries, userID)
+		return
+	}
+}
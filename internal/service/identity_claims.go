@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/auth"
+)
+
+// ClaimsIdentityClient takes roles from the verified bearer token of the
+// current request instead of asking service-identity. It only works with JWT
+// authentication, since other principals carry no roles.
+type ClaimsIdentityClient struct{}
+
+func NewClaimsIdentityClient() *ClaimsIdentityClient {
+	return &ClaimsIdentityClient{}
+}
+
+func (c *ClaimsIdentityClient) GetMe(ctx context.Context, userID uuid.UUID) (IdentityUser, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || principal.Source != auth.SourceJWT || principal.UserID != userID {
+		return IdentityUser{}, ErrUnauthorized
+	}
+
+	roles := make([]IdentityRole, 0, len(principal.Roles))
+	for _, role := range principal.Roles {
+		roles = append(roles, IdentityRole{Name: role.Name, ClassID: role.ClassID, CourseCode: role.CourseCode})
+	}
+	return IdentityUser{ID: principal.UserID, Roles: roles}, nil
+}
@@ -5,25 +5,71 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"service-timetable/internal/metrics"
+	"service-timetable/internal/tracing"
 )
 
 type IdentityHTTPClient struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL        string
+	httpClient     *http.Client
+	maxRetries     int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	breaker        *CircuitBreaker
+}
+
+type IdentityHTTPClientConfig struct {
+	BaseURL        string
+	HTTPClient     *http.Client
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	Breaker        *CircuitBreaker
 }
 
-func NewIdentityHTTPClient(baseURL string, httpClient *http.Client) *IdentityHTTPClient {
+func NewIdentityHTTPClient(config IdentityHTTPClientConfig) *IdentityHTTPClient {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = DefaultIdentityHTTPClient()
+	}
 	return &IdentityHTTPClient{
-		baseURL:    strings.TrimRight(baseURL, "/"),
-		httpClient: httpClient,
+		baseURL:        strings.TrimRight(config.BaseURL, "/"),
+		httpClient:     httpClient,
+		maxRetries:     config.MaxRetries,
+		retryBaseDelay: config.RetryBaseDelay,
+		retryMaxDelay:  config.RetryMaxDelay,
+		breaker:        config.Breaker,
 	}
 }
 
+// retryableError marks failures worth another attempt: 5xx responses and
+// timeouts. Everything else is returned to the caller straight away.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
 type identityMeResponse struct {
 	User     identityUser   `json:"user"`
 	Roles    []identityRole `json:"roles"`
@@ -37,34 +83,144 @@ type identityUser struct {
 }
 
 type identityRole struct {
-	Name    string     `json:"name"`
-	ClassID *uuid.UUID `json:"class_id"`
+	Name       string     `json:"name"`
+	ClassID    *uuid.UUID `json:"class_id"`
+	CourseCode string     `json:"course_code"`
 }
 
-func (c *IdentityHTTPClient) GetMe(ctx context.Context, userID uuid.UUID) (IdentityUser, error) {
+func (c *IdentityHTTPClient) GetMe(ctx context.Context, userID uuid.UUID) (_ IdentityUser, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "IdentityHTTPClient.GetMe", trace.WithAttributes(attribute.String("user.id", userID.String())))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	if c.baseURL == "" {
 		return IdentityUser{}, ErrInvalidInput
 	}
 
+	for attempt := 0; ; attempt++ {
+		if c.breaker != nil {
+			if err := c.breaker.Allow(); err != nil {
+				metrics.IdentityRejected()
+				slog.WarnContext(ctx, "identity lookup refused, circuit breaker open", "user_id", userID)
+				return IdentityUser{}, err
+			}
+		}
+
+		start := time.Now()
+		user, err := c.getMeOnce(ctx, userID)
+		var retryable *retryableError
+		isRetryable := errors.As(err, &retryable)
+		elapsed := time.Since(start)
+		outcome := identityOutcome(err, isRetryable)
+		metrics.ObserveIdentityRequest(outcome, elapsed)
+		slog.DebugContext(ctx, "identity lookup", "user_id", userID, "attempt", attempt+1, "outcome", outcome, "duration_ms", elapsed.Milliseconds())
+		if c.breaker != nil {
+			// A caller that gave up says nothing about the identity service.
+			if ctx.Err() != nil {
+				c.breaker.Abandon()
+			} else {
+				c.breaker.Record(identityResponded(err))
+			}
+		}
+		if !isRetryable {
+			return user, err
+		}
+		if attempt >= c.maxRetries {
+			slog.WarnContext(ctx, "identity lookup failed", "user_id", userID, "attempts", attempt+1, "error", retryable.err)
+			return IdentityUser{}, retryable.err
+		}
+		delay := c.backoff(attempt)
+		slog.WarnContext(ctx, "identity lookup failed, retrying", "user_id", userID, "attempt", attempt+1, "retry_in_ms", delay.Milliseconds(), "error", retryable.err)
+		if err := sleepWithContext(ctx, delay); err != nil {
+			return IdentityUser{}, err
+		}
+	}
+}
+
+// identityResponded reports whether a lookup got a real answer from the
+// identity service: the user, or a 401, 403 or 404. Transport errors, 5xx and
+// unexpected responses count against the circuit breaker.
+func identityResponded(err error) bool {
+	return err == nil || errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnauthorized)
+}
+
+func identityOutcome(err error, retryable bool) string {
+	switch {
+	case err == nil:
+		return metrics.IdentityOK
+	case retryable:
+		return metrics.IdentityUnavailable
+	case errors.Is(err, ErrNotFound):
+		return metrics.IdentityNotFound
+	case errors.Is(err, ErrUnauthorized):
+		return metrics.IdentityUnauthorized
+	default:
+		return metrics.IdentityError
+	}
+}
+
+func (c *IdentityHTTPClient) BreakerState() string {
+	if c.breaker == nil {
+		return BreakerClosed
+	}
+	return c.breaker.State()
+}
+
+// backoff returns a full-jitter exponential delay for the given attempt.
+func (c *IdentityHTTPClient) backoff(attempt int) time.Duration {
+	if c.retryBaseDelay <= 0 {
+		return 0
+	}
+	ceiling := c.retryBaseDelay << attempt
+	if c.retryMaxDelay > 0 && (ceiling > c.retryMaxDelay || ceiling <= 0) {
+		ceiling = c.retryMaxDelay
+	}
+	return rand.N(ceiling + 1)
+}
+
+func (c *IdentityHTTPClient) getMeOnce(ctx context.Context, userID uuid.UUID) (IdentityUser, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "GET /me", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/me", nil)
 	if err != nil {
 		return IdentityUser{}, err
 	}
 	req.Header.Set("X-User-ID", userID.String())
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	span.SetAttributes(
+		attribute.String("http.request.method", http.MethodGet),
+		attribute.String("url.full", req.URL.String()),
+	)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		var netErr net.Error
+		if ctx.Err() == nil && errors.As(err, &netErr) && netErr.Timeout() {
+			return IdentityUser{}, &retryableError{err: err}
+		}
 		return IdentityUser{}, err
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, resp.Status)
+	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
+	switch {
+	case resp.StatusCode == http.StatusOK:
 		// continue
-	case http.StatusNotFound:
+	case resp.StatusCode == http.StatusNotFound:
 		return IdentityUser{}, ErrNotFound
-	case http.StatusUnauthorized, http.StatusForbidden:
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
 		return IdentityUser{}, ErrUnauthorized
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return IdentityUser{}, &retryableError{err: fmt.Errorf("identity service unexpected status: %d", resp.StatusCode)}
 	default:
 		return IdentityUser{}, fmt.Errorf("identity service unexpected status: %d", resp.StatusCode)
 	}
@@ -77,7 +233,7 @@ func (c *IdentityHTTPClient) GetMe(ctx context.Context, userID uuid.UUID) (Ident
 
 	roles := make([]IdentityRole, 0, len(body.Roles))
 	for _, role := range body.Roles {
-		roles = append(roles, IdentityRole{Name: role.Name, ClassID: role.ClassID})
+		roles = append(roles, IdentityRole{Name: role.Name, ClassID: role.ClassID, CourseCode: role.CourseCode})
 	}
 
 	resolvedUserID := body.User.ID
@@ -95,3 +251,14 @@ func (c *IdentityHTTPClient) GetMe(ctx context.Context, userID uuid.UUID) (Ident
 func DefaultIdentityHTTPClient() *http.Client {
 	return &http.Client{Timeout: 5 * time.Second}
 }
+
+func sleepWithContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
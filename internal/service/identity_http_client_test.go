@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestIdentityClient(baseURL string, breaker *CircuitBreaker) *IdentityHTTPClient {
+	return NewIdentityHTTPClient(IdentityHTTPClientConfig{
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{Timeout: time.Second},
+		Breaker:    breaker,
+	})
+}
+
+// closedURL returns the URL of a listener that is no longer accepting
+// connections, so requests to it are refused.
+func closedURL(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	url := "http://" + listener.Addr().String()
+	listener.Close()
+	return url
+}
+
+func TestIdentityClientBreakerOutcomes(t *testing.T) {
+	userID := uuid.New()
+	respond := func(status int, body string) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return server.URL
+	}
+
+	tests := []struct {
+		name      string
+		baseURL   string
+		wantState string
+	}{
+		{name: "user", baseURL: respond(http.StatusOK, `{"user": {"id": "`+userID.String()+`"}}`), wantState: BreakerClosed},
+		{name: "not found", baseURL: respond(http.StatusNotFound, ""), wantState: BreakerClosed},
+		{name: "unauthorized", baseURL: respond(http.StatusUnauthorized, ""), wantState: BreakerClosed},
+		{name: "forbidden", baseURL: respond(http.StatusForbidden, ""), wantState: BreakerClosed},
+		{name: "server error", baseURL: respond(http.StatusBadGateway, ""), wantState: BreakerOpen},
+		{name: "unexpected status", baseURL: respond(http.StatusTeapot, ""), wantState: BreakerOpen},
+		{name: "malformed body", baseURL: respond(http.StatusOK, "{"), wantState: BreakerOpen},
+		{name: "connection refused", baseURL: closedURL(t), wantState: BreakerOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker(1, time.Minute)
+			_, _ = newTestIdentityClient(tt.baseURL, breaker).GetMe(context.Background(), userID)
+			if state := breaker.State(); state != tt.wantState {
+				t.Errorf("breaker state = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestIdentityClientCancelledCallDoesNotCloseBreaker(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.clock = func() time.Time { return now }
+	client := newTestIdentityClient(closedURL(t), breaker)
+
+	if _, err := client.GetMe(context.Background(), uuid.New()); err == nil {
+		t.Fatal("GetMe: expected an error")
+	}
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("breaker state = %s, want %s", state, BreakerOpen)
+	}
+
+	// The half-open trial is cancelled by its caller: the breaker stays
+	// half-open and lets the next trial through.
+	now = now.Add(2 * time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GetMe(ctx, uuid.New()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetMe error = %v, want context.Canceled", err)
+	}
+	if state := breaker.State(); state != BreakerHalfOpen {
+		t.Fatalf("breaker state = %s, want %s", state, BreakerHalfOpen)
+	}
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow after a cancelled trial: %v", err)
+	}
+}
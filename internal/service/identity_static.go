@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// StaticIdentityClient serves users and roles from a local YAML or JSON file,
+// for local development and integration tests without service-identity.
+type StaticIdentityClient struct {
+	users map[uuid.UUID]IdentityUser
+}
+
+type staticIdentityFile struct {
+	Users []staticIdentityUser `json:"users" yaml:"users"`
+}
+
+type staticIdentityUser struct {
+	ID    string               `json:"id" yaml:"id"`
+	Name  string               `json:"name" yaml:"name"`
+	Roles []staticIdentityRole `json:"roles" yaml:"roles"`
+}
+
+type staticIdentityRole struct {
+	Name       string `json:"name" yaml:"name"`
+	ClassID    string `json:"class_id" yaml:"class_id"`
+	CourseCode string `json:"course_code" yaml:"course_code"`
+}
+
+// LoadStaticIdentityClient reads and validates path. The format follows the
+// file extension: .yaml/.yml or .json.
+func LoadStaticIdentityClient(path string) (*StaticIdentityClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read identity file: %w", err)
+	}
+
+	var file staticIdentityFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	default:
+		return nil, fmt.Errorf("identity file %s: extension must be .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode identity file: %w", err)
+	}
+
+	users := make(map[uuid.UUID]IdentityUser, len(file.Users))
+	for i, entry := range file.Users {
+		user, err := entry.toIdentityUser()
+		if err != nil {
+			return nil, fmt.Errorf("identity file user %d: %w", i, err)
+		}
+		if _, ok := users[user.ID]; ok {
+			return nil, fmt.Errorf("identity file user %d: duplicate id %s", i, user.ID)
+		}
+		users[user.ID] = user
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("identity file %s defines no users", path)
+	}
+
+	return &StaticIdentityClient{users: users}, nil
+}
+
+func (u staticIdentityUser) toIdentityUser() (IdentityUser, error) {
+	id, err := uuid.Parse(u.ID)
+	if err != nil {
+		return IdentityUser{}, fmt.Errorf("id %q is not a UUID", u.ID)
+	}
+
+	user := IdentityUser{ID: id, Roles: make([]IdentityRole, 0, len(u.Roles))}
+	for _, role := range u.Roles {
+		if role.Name == "" {
+			return IdentityUser{}, fmt.Errorf("user %s: role name is required", id)
+		}
+		identityRole := IdentityRole{Name: role.Name, CourseCode: role.CourseCode}
+		if role.ClassID != "" {
+			classID, err := uuid.Parse(role.ClassID)
+			if err != nil {
+				return IdentityUser{}, fmt.Errorf("user %s: role %s class_id %q is not a UUID", id, role.Name, role.ClassID)
+			}
+			identityRole.ClassID = &classID
+		}
+		if role.Name == "cr" && identityRole.ClassID == nil {
+			return IdentityUser{}, fmt.Errorf("user %s: role cr requires class_id", id)
+		}
+		user.Roles = append(user.Roles, identityRole)
+	}
+	return user, nil
+}
+
+func (c *StaticIdentityClient) GetMe(ctx context.Context, userID uuid.UUID) (IdentityUser, error) {
+	user, ok := c.users[userID]
+	if !ok {
+		return IdentityUser{}, ErrNotFound
+	}
+	return user, nil
+}
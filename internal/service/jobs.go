@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/repository"
+)
+
+// AuthorizeJobStatus reports whether the requester may see the status of
+// scheduled jobs.
+func (s *TimetableService) AuthorizeJobStatus(ctx context.Context, requesterID uuid.UUID) error {
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return err
+	}
+	return s.authorize(user, CapViewJobs, Resource{})
+}
+
+// PurgePublishedOutbox deletes outbox events that were published and
+// created before the cutoff.
+func (s *TimetableService) PurgePublishedOutbox(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		var err error
+		deleted, err = repos.Outbox.DeletePublishedBefore(ctx, before)
+		return err
+	})
+	return deleted, err
+}
+
+// PurgeExpiredIdempotencyKeys deletes idempotency keys that can no longer be
+// replayed.
+func (s *TimetableService) PurgeExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		var err error
+		deleted, err = repos.Idempotency.DeleteCreatedBefore(ctx, now.Add(-idempotencyKeyTTL))
+		return err
+	})
+	return deleted, err
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultLocale = "en"
+
+// localeFormat names weekdays and months in a locale. Weekdays start on
+// Sunday, as time.Weekday does; longDate takes the day, month name and year.
+type localeFormat struct {
+	weekdays [7]string
+	months   [12]string
+	longDate string
+}
+
+var locales = map[string]localeFormat{
+	"en": {
+		weekdays: [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		months: [12]string{
+			"January", "February", "March", "April", "May", "June",
+			"July", "August", "September", "October", "November", "December",
+		},
+		longDate: "%d %s %d",
+	},
+	"hi": {
+		weekdays: [7]string{"रविवार", "सोमवार", "मंगलवार", "बुधवार", "गुरुवार", "शुक्रवार", "शनिवार"},
+		months: [12]string{
+			"जनवरी", "फ़रवरी", "मार्च", "अप्रैल", "मई", "जून",
+			"जुलाई", "अगस्त", "सितंबर", "अक्टूबर", "नवंबर", "दिसंबर",
+		},
+		longDate: "%d %s %d",
+	},
+	"ta": {
+		weekdays: [7]string{"ஞாயிறு", "திங்கள்", "செவ்வாய்", "புதன்", "வியாழன்", "வெள்ளி", "சனி"},
+		months: [12]string{
+			"ஜனவரி", "பிப்ரவரி", "மார்ச்", "ஏப்ரல்", "மே", "ஜூன்",
+			"ஜூலை", "ஆகஸ்ட்", "செப்டம்பர்", "அக்டோபர்", "நவம்பர்", "டிசம்பர்",
+		},
+		longDate: "%d %s, %d",
+	},
+}
+
+// formatFor returns the format of locale, or of defaultLocale for locales
+// that are not supported, such as one stored before its support was removed.
+func formatFor(locale string) localeFormat {
+	if format, ok := locales[locale]; ok {
+		return format
+	}
+	return locales[defaultLocale]
+}
+
+func (f localeFormat) weekday(date time.Time) string {
+	return f.weekdays[date.Weekday()]
+}
+
+func (f localeFormat) formatLongDate(date time.Time) string {
+	return fmt.Sprintf(f.longDate, date.Day(), f.months[date.Month()-1], date.Year())
+}
+
+func validLocale(locale string) bool {
+	_, ok := locales[locale]
+	return ok
+}
+
+func unsupportedLocaleMessage() string {
+	return "must be one of: " + strings.Join(slices.Sorted(maps.Keys(locales)), ", ")
+}
+
+// templatesByLocale returns the template of every configured locale: the
+// primary template under locale, and the localized ones under theirs.
+func templatesByLocale(locale string, template string, localized map[string]string) map[string]string {
+	templates := make(map[string]string, len(localized)+1)
+	maps.Copy(templates, localized)
+	templates[locale] = template
+	return templates
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTemplateDataLocales(t *testing.T) {
+	// A Monday in February, whose Hindi name needs a nukta.
+	date := time.Date(2026, 2, 9, 0, 0, 0, 0, time.Local)
+	const text = `{{.weekday}}, {{.long_date}} ({{.date}})`
+
+	tests := []struct {
+		name   string
+		locale string
+		want   string
+	}{
+		{name: "en", locale: "en", want: "Monday, 9 February 2026 (2026-02-09)"},
+		{name: "hi", locale: "hi", want: "सोमवार, 9 फ़रवरी 2026 (2026-02-09)"},
+		{name: "ta", locale: "ta", want: "திங்கள், 9 பிப்ரவரி, 2026 (2026-02-09)"},
+		{name: "unknown locale", locale: "fr", want: "Monday, 9 February 2026 (2026-02-09)"},
+		{name: "no locale", locale: "", want: "Monday, 9 February 2026 (2026-02-09)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := renderTemplate(context.Background(), text, templateData(tt.locale, date, nil, nil, ""))
+			if err != nil {
+				t.Fatalf("renderTemplate: %v", err)
+			}
+			if message != tt.want {
+				t.Errorf("message = %q, want %q", message, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalesAreComplete(t *testing.T) {
+	for locale, format := range locales {
+		for i, name := range format.weekdays {
+			if name == "" {
+				t.Errorf("%s: weekday %s has no name", locale, time.Weekday(i))
+			}
+		}
+		for i, name := range format.months {
+			if name == "" {
+				t.Errorf("%s: month %s has no name", locale, time.Month(i+1))
+			}
+		}
+	}
+}
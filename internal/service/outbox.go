@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+const (
+	defaultOutboxLimit = 100
+	maxOutboxLimit     = 1000
+)
+
+// ListOutboxEvents pages through the outbox in creation order, starting
+// after afterID when it is set. A limit of 0 selects the default.
+func (s *TimetableService) ListOutboxEvents(ctx context.Context, requesterID uuid.UUID, afterID *uuid.UUID, limit int) ([]domain.OutboxEvent, error) {
+	if limit == 0 {
+		limit = defaultOutboxLimit
+	}
+	if limit < 1 || limit > maxOutboxLimit {
+		return nil, invalidField("limit", "must be between 1 and 1000")
+	}
+
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(user, CapReadOutbox, Resource{}); err != nil {
+		return nil, err
+	}
+
+	var events []domain.OutboxEvent
+	err = s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		var err error
+		events, err = repos.Outbox.ListAfter(ctx, afterID, limit)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	})
+	return events, err
+}
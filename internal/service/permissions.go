@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+type Capability string
+
+const (
+	CapEditOverrides  Capability = "edit-overrides"
+	CapEditDefaults   Capability = "edit-defaults"
+	CapManageSettings Capability = "manage-settings"
+	CapViewAudit      Capability = "view-audit"
+	CapDelegate       Capability = "delegate"
+	CapLockDays       Capability = "lock-days"
+	CapBypassCutoff   Capability = "bypass-cutoff"
+	CapReadOutbox     Capability = "read-outbox"
+	CapManageAPIKeys  Capability = "manage-api-keys"
+	CapViewJobs       Capability = "view-jobs"
+)
+
+var knownCapabilities = map[Capability]bool{
+	CapEditOverrides:  true,
+	CapEditDefaults:   true,
+	CapManageSettings: true,
+	CapViewAudit:      true,
+	CapDelegate:       true,
+	CapLockDays:       true,
+	CapBypassCutoff:   true,
+	CapReadOutbox:     true,
+	CapManageAPIKeys:  true,
+	CapViewJobs:       true,
+}
+
+const (
+	// ScopeGlobal grants a role's capabilities on every class.
+	ScopeGlobal = "global"
+	// ScopeAssigned limits a role to the class and/or course named on the
+	// role itself, e.g. a CR of one class or faculty teaching one course.
+	ScopeAssigned = "assigned"
+)
+
+type RolePermissions struct {
+	Scope        string
+	Capabilities []Capability
+}
+
+// Permissions maps identity role names to capabilities.
+type Permissions struct {
+	roles map[string]RolePermissions
+}
+
+func DefaultPermissions() *Permissions {
+	return &Permissions{roles: map[string]RolePermissions{
+		"admin": {
+			Scope: ScopeGlobal,
+			Capabilities: []Capability{
+				CapEditOverrides, CapEditDefaults, CapManageSettings, CapViewAudit, CapDelegate, CapLockDays, CapBypassCutoff,
+				CapReadOutbox, CapManageAPIKeys, CapViewJobs,
+			},
+		},
+		"faculty": {
+			Scope:        ScopeAssigned,
+			Capabilities: []Capability{CapEditOverrides, CapEditDefaults, CapManageSettings, CapViewAudit, CapDelegate, CapLockDays, CapBypassCutoff},
+		},
+		"cr": {
+			Scope:        ScopeAssigned,
+			Capabilities: []Capability{CapEditOverrides, CapDelegate},
+		},
+	}}
+}
+
+type permissionsFile struct {
+	Roles map[string]permissionsFileRole `json:"roles" yaml:"roles"`
+}
+
+type permissionsFileRole struct {
+	Scope        string   `json:"scope" yaml:"scope"`
+	Capabilities []string `json:"capabilities" yaml:"capabilities"`
+}
+
+// LoadPermissions reads a role-to-capability mapping from a YAML or JSON
+// file. The file replaces the defaults entirely.
+func LoadPermissions(path string) (*Permissions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read permissions file: %w", err)
+	}
+
+	var file permissionsFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	default:
+		return nil, fmt.Errorf("permissions file %s: extension must be .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode permissions file: %w", err)
+	}
+
+	permissions := &Permissions{roles: make(map[string]RolePermissions, len(file.Roles))}
+	for name, role := range file.Roles {
+		if role.Scope != ScopeGlobal && role.Scope != ScopeAssigned {
+			return nil, fmt.Errorf("permissions role %s: scope must be %s or %s", name, ScopeGlobal, ScopeAssigned)
+		}
+		capabilities := make([]Capability, 0, len(role.Capabilities))
+		for _, value := range role.Capabilities {
+			capability := Capability(value)
+			if !knownCapabilities[capability] {
+				return nil, fmt.Errorf("permissions role %s: unknown capability %q", name, value)
+			}
+			capabilities = append(capabilities, capability)
+		}
+		permissions.roles[name] = RolePermissions{Scope: role.Scope, Capabilities: capabilities}
+	}
+	return permissions, nil
+}
+
+// Resource is what an action touches: a class and, when the action is about
+// particular courses, their codes. Empty course codes are ignored.
+type Resource struct {
+	ClassID     uuid.UUID
+	CourseCodes []string
+}
+
+// Allows reports whether user holds capability on resource. Every course of
+// the resource must be covered by some role; a resource without courses needs
+// a role that covers the whole class. API keys are judged by their scopes
+// alone.
+func (p *Permissions) Allows(user IdentityUser, capability Capability, resource Resource) bool {
+	if user.APIKey != nil {
+		return user.APIKey.allows(capability, resource.ClassID)
+	}
+
+	courses := make([]string, 0, len(resource.CourseCodes))
+	for _, course := range resource.CourseCodes {
+		if course != "" {
+			courses = append(courses, course)
+		}
+	}
+	if len(courses) == 0 {
+		courses = []string{""}
+	}
+
+	for _, course := range courses {
+		if !p.anyRoleCovers(user, capability, resource.ClassID, course) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Permissions) anyRoleCovers(user IdentityUser, capability Capability, classID uuid.UUID, course string) bool {
+	for _, role := range user.Roles {
+		grant, ok := p.roles[role.Name]
+		if !ok || !slices.Contains(grant.Capabilities, capability) {
+			continue
+		}
+		if grant.Scope == ScopeGlobal {
+			return true
+		}
+		if roleCovers(role, classID, course) {
+			return true
+		}
+	}
+	return false
+}
+
+// roleCovers applies an assigned role's own class and course restrictions.
+// A role that names neither covers nothing.
+func roleCovers(role IdentityRole, classID uuid.UUID, course string) bool {
+	if role.ClassID == nil && role.CourseCode == "" {
+		return false
+	}
+	if role.ClassID != nil && *role.ClassID != classID {
+		return false
+	}
+	if role.CourseCode != "" && !strings.EqualFold(role.CourseCode, course) {
+		return false
+	}
+	return true
+}
+
+// lookupRequester fetches the requester's roles from the identity provider,
+// or takes the scopes of the API key the request was authenticated with.
+func (s *TimetableService) lookupRequester(ctx context.Context, requesterID uuid.UUID) (IdentityUser, error) {
+	if grant, ok := apiKeyFromContext(ctx, requesterID); ok {
+		return IdentityUser{ID: requesterID, APIKey: grant}, nil
+	}
+
+	user, err := s.identity.GetMe(ctx, requesterID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return IdentityUser{}, ErrNotFound
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			return IdentityUser{}, ErrUnauthorized
+		}
+		return IdentityUser{}, err
+	}
+	return user, nil
+}
+
+func (s *TimetableService) authorize(user IdentityUser, capability Capability, resource Resource) error {
+	if !s.permissions.Allows(user, capability, resource) {
+		return ErrUnauthorized
+	}
+	return nil
+}
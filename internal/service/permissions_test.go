@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/auth"
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+var (
+	classA = uuid.MustParse("0b6f6f0e-8a55-4a5e-9f3c-1a2b3c4d5e01")
+	classB = uuid.MustParse("0b6f6f0e-8a55-4a5e-9f3c-1a2b3c4d5e02")
+)
+
+func role(name string, classID *uuid.UUID, course string) IdentityRole {
+	return IdentityRole{Name: name, ClassID: classID, CourseCode: course}
+}
+
+func userWith(roles ...IdentityRole) IdentityUser {
+	return IdentityUser{ID: uuid.New(), Roles: roles}
+}
+
+func apiKeyUser(scopes []string, classIDs ...uuid.UUID) IdentityUser {
+	return IdentityUser{ID: uuid.New(), APIKey: &APIKeyGrant{Name: "test", Scopes: scopes, ClassIDs: classIDs}}
+}
+
+func TestPermissionsAllows(t *testing.T) {
+	admin := userWith(role("admin", nil, ""))
+	facultyCS101 := userWith(role("faculty", &classA, "CS101"))
+	facultyClassA := userWith(role("faculty", &classA, ""))
+	facultyAnyClass := userWith(role("faculty", nil, "CS101"))
+	facultyTwoCourses := userWith(role("faculty", &classA, "CS101"), role("faculty", &classA, "MA201"))
+	crClassA := userWith(role("cr", &classA, ""))
+	crUnassigned := userWith(role("cr", nil, ""))
+	visitor := userWith(role("visitor", &classA, ""))
+	noRoles := userWith()
+	overridesKey := apiKeyUser([]string{APIScopeOverridesWrite}, classA)
+	outboxKey := apiKeyUser([]string{APIScopeOutboxRead})
+	readKey := apiKeyUser([]string{APIScopeTimetableRead})
+	adminKey := apiKeyUser(nil)
+	adminKey.Roles = admin.Roles
+
+	tests := []struct {
+		name       string
+		user       IdentityUser
+		capability Capability
+		resource   Resource
+		want       bool
+	}{
+		{"admin edits defaults of any class", admin, CapEditDefaults, Resource{ClassID: classB, CourseCodes: []string{"CS101"}}, true},
+		{"admin manages API keys", admin, CapManageAPIKeys, Resource{}, true},
+		{"admin views jobs", admin, CapViewJobs, Resource{}, true},
+
+		{"faculty edits own course", facultyCS101, CapEditOverrides, Resource{ClassID: classA, CourseCodes: []string{"CS101"}}, true},
+		{"faculty course match ignores case", facultyCS101, CapEditOverrides, Resource{ClassID: classA, CourseCodes: []string{"cs101"}}, true},
+		{"faculty ignores empty course codes", facultyCS101, CapEditOverrides, Resource{ClassID: classA, CourseCodes: []string{"", "CS101"}}, true},
+		{"faculty own course in other class", facultyCS101, CapEditOverrides, Resource{ClassID: classB, CourseCodes: []string{"CS101"}}, false},
+		{"faculty replacing other course", facultyCS101, CapEditOverrides, Resource{ClassID: classA, CourseCodes: []string{"CS101", "MA201"}}, false},
+		{"faculty of a course on the whole class", facultyCS101, CapManageSettings, Resource{ClassID: classA}, false},
+		{"faculty of a class on the whole class", facultyClassA, CapManageSettings, Resource{ClassID: classA}, true},
+		{"faculty of a class on other class", facultyClassA, CapManageSettings, Resource{ClassID: classB}, false},
+		{"faculty of a course in any class", facultyAnyClass, CapEditOverrides, Resource{ClassID: classB, CourseCodes: []string{"CS101"}}, true},
+		{"faculty roles together cover both courses", facultyTwoCourses, CapEditOverrides, Resource{ClassID: classA, CourseCodes: []string{"CS101", "MA201"}}, true},
+		{"faculty manages API keys", facultyClassA, CapManageAPIKeys, Resource{}, false},
+		{"faculty reads outbox", facultyClassA, CapReadOutbox, Resource{}, false},
+
+		{"cr edits overrides of own class", crClassA, CapEditOverrides, Resource{ClassID: classA, CourseCodes: []string{"MA201"}}, true},
+		{"cr delegates in own class", crClassA, CapDelegate, Resource{ClassID: classA}, true},
+		{"cr edits overrides of other class", crClassA, CapEditOverrides, Resource{ClassID: classB}, false},
+		{"cr edits defaults", crClassA, CapEditDefaults, Resource{ClassID: classA}, false},
+		{"cr locks days", crClassA, CapLockDays, Resource{ClassID: classA}, false},
+		{"cr bypasses cutoff", crClassA, CapBypassCutoff, Resource{ClassID: classA}, false},
+		{"cr without class or course", crUnassigned, CapEditOverrides, Resource{ClassID: classA}, false},
+
+		{"unknown role", visitor, CapEditOverrides, Resource{ClassID: classA}, false},
+		{"no roles", noRoles, CapEditOverrides, Resource{ClassID: classA}, false},
+
+		{"overrides key in its class", overridesKey, CapEditOverrides, Resource{ClassID: classA, CourseCodes: []string{"CS101", "MA201"}}, true},
+		{"overrides key in other class", overridesKey, CapEditOverrides, Resource{ClassID: classB}, false},
+		{"overrides key edits defaults", overridesKey, CapEditDefaults, Resource{ClassID: classA}, false},
+		{"outbox key reads outbox", outboxKey, CapReadOutbox, Resource{}, true},
+		{"read key reads outbox", readKey, CapReadOutbox, Resource{}, false},
+		{"key ignores roles", adminKey, CapManageSettings, Resource{ClassID: classA}, false},
+	}
+
+	permissions := DefaultPermissions()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permissions.Allows(tt.user, tt.capability, tt.resource); got != tt.want {
+				t.Errorf("Allows(%s) = %v, want %v", tt.capability, got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeIdentity map[uuid.UUID]IdentityUser
+
+func (f fakeIdentity) GetMe(ctx context.Context, userID uuid.UUID) (IdentityUser, error) {
+	user, ok := f[userID]
+	if !ok {
+		return IdentityUser{}, ErrNotFound
+	}
+	return user, nil
+}
+
+// fakeTxManager runs transactions against repos. Repositories a test does not
+// expect to be used are left nil and panic when called.
+type fakeTxManager struct {
+	repos repository.TxRepositories
+}
+
+func (m fakeTxManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.TxRepositories) error) error {
+	return fn(ctx, m.repos)
+}
+
+type fakeRevisions struct {
+	repository.DefaultRevisionRepository
+}
+
+func (fakeRevisions) Share(ctx context.Context, classID uuid.UUID, weekday int) (int64, error) {
+	return 0, nil
+}
+
+func (fakeRevisions) Bump(ctx context.Context, classID uuid.UUID, weekday int) (int64, error) {
+	return 1, nil
+}
+
+type fakeDayVersions struct {
+	repository.DayVersionRepository
+}
+
+func (fakeDayVersions) Bump(ctx context.Context, classID uuid.UUID, date time.Time) (int64, error) {
+	return 1, nil
+}
+
+type fakeDefaultSlots struct {
+	repository.DefaultSlotRepository
+	slots []domain.DefaultSlot
+}
+
+func (f fakeDefaultSlots) ListByWeekday(ctx context.Context, classID uuid.UUID, weekday int) ([]domain.DefaultSlot, error) {
+	return f.slots, nil
+}
+
+type fakeOverrides struct {
+	repository.DailyOverrideRepository
+	overrides []domain.DailyOverride
+}
+
+func (f fakeOverrides) ListByDate(ctx context.Context, classID uuid.UUID, date time.Time) ([]domain.DailyOverride, error) {
+	return f.overrides, nil
+}
+
+type fakeDelegations struct {
+	repository.DelegationRepository
+	delegation domain.Delegation
+	active     bool
+}
+
+func (f fakeDelegations) Get(ctx context.Context, id uuid.UUID) (domain.Delegation, error) {
+	return f.delegation, nil
+}
+
+func (f fakeDelegations) HasActive(ctx context.Context, delegateID uuid.UUID, classID uuid.UUID, date time.Time) (bool, error) {
+	return f.active, nil
+}
+
+func newPermissionTestService(users fakeIdentity, delegations fakeDelegations) *TimetableService {
+	nine := time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)
+	repos := repository.TxRepositories{
+		Revisions:   fakeRevisions{},
+		DayVersions: fakeDayVersions{},
+		DefaultSlots: fakeDefaultSlots{slots: []domain.DefaultSlot{
+			{ClassID: classA, SlotIndex: 1, CourseCode: "MA201", StartTime: nine, EndTime: nine.Add(time.Hour), Venue: "R1"},
+		}},
+		Overrides:   fakeOverrides{},
+		Delegations: delegations,
+	}
+	service := NewTimetableService(fakeTxManager{repos: repos}, users, DefaultPermissions())
+	service.clock = func() time.Time { return time.Date(2026, 10, 19, 8, 0, 0, 0, time.Local) }
+	return service
+}
+
+func TestAuthorizeOverrideDelegation(t *testing.T) {
+	service := newPermissionTestService(fakeIdentity{}, fakeDelegations{})
+	stranger := userWith(role("visitor", nil, ""))
+	resource := Resource{ClassID: classA, CourseCodes: []string{"CS101"}}
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+	readKey := apiKeyUser([]string{APIScopeTimetableRead})
+	readKey.ID = stranger.ID
+
+	tests := []struct {
+		name   string
+		user   IdentityUser
+		active bool
+		want   error
+	}{
+		{name: "active delegation", user: stranger, active: true, want: nil},
+		{name: "no delegation", user: stranger, active: false, want: ErrUnauthorized},
+		{name: "API key with its ID delegated", user: readKey, active: true, want: ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.TxRepositories{Delegations: fakeDelegations{active: tt.active}}
+			err := service.authorizeOverride(context.Background(), repos, tt.user, resource, date)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("authorizeOverride = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestGuardedMethodsDeny calls every guarded service method as a requester
+// lacking the capability it needs.
+func TestGuardedMethodsDeny(t *testing.T) {
+	facultyCS101 := userWith(role("faculty", &classA, "CS101"))
+	facultyClassA := userWith(role("faculty", &classA, ""))
+	crClassA := userWith(role("cr", &classA, ""))
+	crClassB := userWith(role("cr", &classB, ""))
+	users := fakeIdentity{
+		facultyCS101.ID:  facultyCS101,
+		facultyClassA.ID: facultyClassA,
+		crClassA.ID:      crClassA,
+		crClassB.ID:      crClassB,
+	}
+	delegation := domain.Delegation{ID: uuid.New(), ClassID: classA, DelegateID: crClassB.ID, GrantedBy: uuid.New()}
+	service := newPermissionTestService(users, fakeDelegations{delegation: delegation})
+
+	ctx := context.Background()
+	tomorrow := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)
+	nine := time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)
+	ten := nine.Add(time.Hour)
+	minutes := 30
+	schedules := []domain.AnnouncementSchedule{{Name: "evening", DaysAhead: 1, AnnounceTime: nine, Template: "{{.date}}"}}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"UpdateTodayOverride replacing another course", func() error {
+			_, err := service.UpdateTodayOverride(ctx, facultyCS101.ID, classA, 1, "CS101", &nine, &ten, "R1", nil, "replaced", "", nil)
+			return err
+		}},
+		{"CreateDailyOverride in another class", func() error {
+			_, err := service.CreateDailyOverride(ctx, crClassB.ID, classA, tomorrow, 1, "", nil, nil, "", nil, "cancelled", "", nil)
+			return err
+		}},
+		{"UpsertDefaultSlot", func() error {
+			return service.UpsertDefaultSlot(ctx, crClassA.ID, classA, 1, "CS101", ten, ten.Add(time.Hour), "R1", nil)
+		}},
+		{"DeleteDefaultSlot", func() error {
+			return service.DeleteDefaultSlot(ctx, crClassA.ID, classA, 1, nine)
+		}},
+		{"GetAnnouncementSettings", func() error {
+			_, err := service.GetAnnouncementSettings(ctx, crClassA.ID, classA)
+			return err
+		}},
+		{"UpdateAnnouncementSettings", func() error {
+			return service.UpdateAnnouncementSettings(ctx, crClassA.ID, classA, "!room:example.org", "en", "{{.date}}", nil, &minutes, false, schedules)
+		}},
+		{"PreviewTemplate", func() error {
+			_, err := service.PreviewTemplate(ctx, crClassA.ID, classA, tomorrow, "en", "{{.date}}")
+			return err
+		}},
+		{"ListAudit", func() error {
+			_, err := service.ListAudit(ctx, crClassA.ID, classA, 0)
+			return err
+		}},
+		{"GrantDelegation by faculty of one course", func() error {
+			_, err := service.GrantDelegation(ctx, facultyCS101.ID, classA, crClassB.ID, tomorrow, tomorrow)
+			return err
+		}},
+		{"ListDelegations by cr of another class", func() error {
+			_, err := service.ListDelegations(ctx, crClassB.ID, classA)
+			return err
+		}},
+		{"RevokeDelegation by its delegate", func() error {
+			return service.RevokeDelegation(ctx, crClassB.ID, delegation.ID)
+		}},
+		{"LockDay", func() error {
+			_, err := service.LockDay(ctx, crClassA.ID, classA, tomorrow, 0, "exam")
+			return err
+		}},
+		{"UnlockDay", func() error {
+			_, err := service.UnlockDay(ctx, crClassA.ID, classA, tomorrow, 0)
+			return err
+		}},
+		{"GetEditPolicy", func() error {
+			_, err := service.GetEditPolicy(ctx, crClassA.ID, classA)
+			return err
+		}},
+		{"UpdateEditPolicy", func() error {
+			_, err := service.UpdateEditPolicy(ctx, crClassA.ID, classA, &minutes)
+			return err
+		}},
+		{"IssueAPIKey", func() error {
+			_, _, err := service.IssueAPIKey(ctx, facultyClassA.ID, "bot", []string{APIScopeTimetableRead}, nil, nil)
+			return err
+		}},
+		{"ListAPIKeys", func() error {
+			_, err := service.ListAPIKeys(ctx, facultyClassA.ID)
+			return err
+		}},
+		{"RevokeAPIKey", func() error {
+			return service.RevokeAPIKey(ctx, facultyClassA.ID, uuid.New())
+		}},
+		{"ListOutboxEvents", func() error {
+			_, err := service.ListOutboxEvents(ctx, facultyClassA.ID, nil, 0)
+			return err
+		}},
+		{"AuthorizeJobStatus", func() error {
+			return service.AuthorizeJobStatus(ctx, facultyClassA.ID)
+		}},
+		{"GetResolvedDay with a key lacking timetable:read", func() error {
+			keyCtx := auth.WithPrincipal(ctx, auth.Principal{
+				UserID: uuid.New(),
+				Source: auth.SourceAPIKey,
+				APIKey: &auth.APIKeyGrant{Name: "bot", Scopes: []string{APIScopeOutboxRead}},
+			})
+			_, err := service.GetResolvedDay(keyCtx, classA, tomorrow)
+			return err
+		}},
+		{"ListOutboxEvents with a key lacking outbox:read", func() error {
+			keyID := uuid.New()
+			keyCtx := auth.WithPrincipal(ctx, auth.Principal{
+				UserID: keyID,
+				Source: auth.SourceAPIKey,
+				APIKey: &auth.APIKeyGrant{Name: "bot", Scopes: []string{APIScopeTimetableRead}},
+			})
+			_, err := service.ListOutboxEvents(keyCtx, keyID, nil, 0)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrUnauthorized) {
+				t.Errorf("error = %v, want %v", err, ErrUnauthorized)
+			}
+		})
+	}
+}
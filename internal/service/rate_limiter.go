@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrRateLimited = errors.New("rate limited")
+
+const (
+	RateLimitScopeRequester = "requester"
+	RateLimitScopeClass     = "class"
+)
+
+// RateLimit is a token bucket refilled at PerMinute tokens a minute up to
+// Burst tokens. A PerMinute of zero or less disables the bucket.
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+func (l RateLimit) enabled() bool {
+	return l.PerMinute > 0
+}
+
+func (l RateLimit) capacity() float64 {
+	if l.Burst <= 0 {
+		return float64(l.PerMinute)
+	}
+	return float64(l.Burst)
+}
+
+func (l RateLimit) perSecond() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// RateLimitDecision describes the most constraining bucket of a request.
+// Reset is the time until that bucket is full again.
+type RateLimitDecision struct {
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+type RateLimitedError struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("too many writes for this %s, retry in %s", e.Scope, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+type tokenBucket struct {
+	limit   RateLimit
+	tokens  float64
+	updated time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(b.limit.capacity(), b.tokens+elapsed*b.limit.perSecond())
+		b.updated = now
+	}
+}
+
+func (b *tokenBucket) full() bool {
+	return b.tokens >= b.limit.capacity()
+}
+
+func (b *tokenBucket) untilTokens(tokens float64) time.Duration {
+	missing := tokens - b.tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.limit.perSecond() * float64(time.Second))
+}
+
+// RateLimiter keeps one token bucket per requester and one per class. A
+// request takes a token from each bucket it falls under, or from none when
+// any of them is empty, so a rejected request costs nothing.
+type RateLimiter struct {
+	requester RateLimit
+	class     RateLimit
+	clock     func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func NewRateLimiter(requester RateLimit, class RateLimit) *RateLimiter {
+	return &RateLimiter{
+		requester: requester,
+		class:     class,
+		clock:     time.Now,
+		buckets:   make(map[string]*tokenBucket),
+	}
+}
+
+// Take spends a token for a write by requesterID, on classID when the write
+// targets a class. It returns a *RateLimitedError when a bucket is empty.
+func (l *RateLimiter) Take(requesterID uuid.UUID, classID *uuid.UUID) (RateLimitDecision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock()
+	l.sweep(now)
+
+	type scoped struct {
+		scope  string
+		bucket *tokenBucket
+	}
+	var buckets []scoped
+	if l.requester.enabled() {
+		buckets = append(buckets, scoped{RateLimitScopeRequester, l.bucket("requester:"+requesterID.String(), l.requester, now)})
+	}
+	if l.class.enabled() && classID != nil {
+		buckets = append(buckets, scoped{RateLimitScopeClass, l.bucket("class:"+classID.String(), l.class, now)})
+	}
+	if len(buckets) == 0 {
+		return RateLimitDecision{}, nil
+	}
+
+	var limited *RateLimitedError
+	for _, b := range buckets {
+		if b.bucket.tokens >= 1 {
+			continue
+		}
+		if wait := b.bucket.untilTokens(1); limited == nil || wait > limited.RetryAfter {
+			limited = &RateLimitedError{Scope: b.scope, RetryAfter: wait}
+		}
+	}
+	if limited == nil {
+		for _, b := range buckets {
+			b.bucket.tokens--
+		}
+	}
+
+	var decision RateLimitDecision
+	for i, b := range buckets {
+		remaining := int(math.Floor(b.bucket.tokens))
+		if i == 0 || remaining < decision.Remaining {
+			decision = RateLimitDecision{
+				Limit:     int(b.bucket.limit.capacity()),
+				Remaining: remaining,
+				Reset:     b.bucket.untilTokens(b.bucket.limit.capacity()),
+			}
+		}
+	}
+	if limited != nil {
+		return decision, limited
+	}
+	return decision, nil
+}
+
+func (l *RateLimiter) bucket(key string, limit RateLimit, now time.Time) *tokenBucket {
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{limit: limit, tokens: limit.capacity(), updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.refill(now)
+	return bucket
+}
+
+// sweep drops full buckets at most once a minute; a full bucket is the same
+// as a missing one.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		bucket.refill(now)
+		if bucket.full() {
+			delete(l.buckets, key)
+		}
+	}
+}
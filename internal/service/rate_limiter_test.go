@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestRateLimiter(requester RateLimit, class RateLimit) (*RateLimiter, *time.Time) {
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(requester, class)
+	limiter.clock = func() time.Time { return now }
+	return limiter, &now
+}
+
+func requireTake(t *testing.T, limiter *RateLimiter, requesterID uuid.UUID, classID *uuid.UUID, wantRemaining int) RateLimitDecision {
+	t.Helper()
+	decision, err := limiter.Take(requesterID, classID)
+	if err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	if decision.Remaining != wantRemaining {
+		t.Fatalf("remaining = %d, want %d", decision.Remaining, wantRemaining)
+	}
+	return decision
+}
+
+func requireLimited(t *testing.T, limiter *RateLimiter, requesterID uuid.UUID, classID *uuid.UUID, wantScope string, wantRetryAfter time.Duration) {
+	t.Helper()
+	_, err := limiter.Take(requesterID, classID)
+	var limited *RateLimitedError
+	if !errors.As(err, &limited) {
+		t.Fatalf("Take error = %v, want a RateLimitedError", err)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("error does not match ErrRateLimited")
+	}
+	if limited.Scope != wantScope || limited.RetryAfter != wantRetryAfter {
+		t.Errorf("limited = %s after %s, want %s after %s", limited.Scope, limited.RetryAfter, wantScope, wantRetryAfter)
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	limiter, now := newTestRateLimiter(RateLimit{PerMinute: 60, Burst: 2}, RateLimit{})
+	requesterID := uuid.New()
+
+	decision := requireTake(t, limiter, requesterID, nil, 1)
+	if decision.Limit != 2 || decision.Reset != time.Second {
+		t.Errorf("decision = %+v, want limit 2 and reset 1s", decision)
+	}
+	requireTake(t, limiter, requesterID, nil, 0)
+	requireLimited(t, limiter, requesterID, nil, RateLimitScopeRequester, time.Second)
+
+	*now = now.Add(500 * time.Millisecond)
+	requireLimited(t, limiter, requesterID, nil, RateLimitScopeRequester, 500*time.Millisecond)
+
+	*now = now.Add(500 * time.Millisecond)
+	requireTake(t, limiter, requesterID, nil, 0)
+
+	// Refilling stops at the burst.
+	*now = now.Add(time.Hour)
+	requireTake(t, limiter, requesterID, nil, 1)
+}
+
+func TestRateLimiterBurstDefaultsToRate(t *testing.T) {
+	limiter, _ := newTestRateLimiter(RateLimit{PerMinute: 3}, RateLimit{})
+	requesterID := uuid.New()
+
+	decision := requireTake(t, limiter, requesterID, nil, 2)
+	if decision.Limit != 3 {
+		t.Errorf("limit = %d, want 3", decision.Limit)
+	}
+	requireTake(t, limiter, requesterID, nil, 1)
+	requireTake(t, limiter, requesterID, nil, 0)
+	requireLimited(t, limiter, requesterID, nil, RateLimitScopeRequester, 20*time.Second)
+}
+
+func TestRateLimiterClassBuckets(t *testing.T) {
+	limiter, now := newTestRateLimiter(RateLimit{PerMinute: 60, Burst: 10}, RateLimit{PerMinute: 6, Burst: 2})
+	alice, bob := uuid.New(), uuid.New()
+
+	// The class bucket is shared by every requester writing to the class,
+	// and is the more constraining one.
+	decision := requireTake(t, limiter, alice, &classA, 1)
+	if decision.Limit != 2 {
+		t.Errorf("limit = %d, want the class limit 2", decision.Limit)
+	}
+	requireTake(t, limiter, bob, &classA, 0)
+	requireLimited(t, limiter, alice, &classA, RateLimitScopeClass, 10*time.Second)
+
+	// Other classes and writes without a class are not affected, and the
+	// rejected write took no token from alice's own bucket.
+	requireTake(t, limiter, alice, &classB, 1)
+	requireTake(t, limiter, alice, nil, 7)
+
+	*now = now.Add(10 * time.Second)
+	requireTake(t, limiter, bob, &classA, 0)
+}
+
+func TestRateLimiterRequesterLimitsAcrossClasses(t *testing.T) {
+	limiter, _ := newTestRateLimiter(RateLimit{PerMinute: 60, Burst: 1}, RateLimit{PerMinute: 60, Burst: 5})
+	requesterID := uuid.New()
+
+	requireTake(t, limiter, requesterID, &classA, 0)
+	requireLimited(t, limiter, requesterID, &classB, RateLimitScopeRequester, time.Second)
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter, _ := newTestRateLimiter(RateLimit{}, RateLimit{PerMinute: -1})
+	for range 100 {
+		decision, err := limiter.Take(uuid.New(), &classA)
+		if err != nil || decision != (RateLimitDecision{}) {
+			t.Fatalf("Take = %+v, %v; want no decision", decision, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+const (
+	maxReminderMinutes = 180
+	// slotRecordRetentionDays is how long reminder and lifecycle event
+	// records are kept.
+	slotRecordRetentionDays = 7
+)
+
+// EmitDueSlotReminders writes a SlotReminder event for every slot of a class
+// with reminders enabled that starts within the class's reminder lead time.
+// Cancelled slots and slots that already started are skipped, and each slot
+// of a date is reminded of at most once per start time: a slot moved after its
+// reminder is reminded of again.
+func (s *TimetableService) EmitDueSlotReminders(ctx context.Context, now time.Time) error {
+	var settings []domain.AnnouncementSettings
+	err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		var err error
+		settings, err = repos.Settings.ListAll(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, setting := range settings {
+		if setting.ReminderMinutes == nil {
+			continue
+		}
+		minutes := *setting.ReminderMinutes
+		for _, date := range reminderDates(now, minutes) {
+			var reminded []domain.Slot
+			err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+				reminded = nil
+				slots, err := s.resolveTimetableWithRepos(ctx, repos, setting.ClassID, date)
+				if err != nil {
+					return err
+				}
+				for _, slot := range slots {
+					if !reminderDue(slot, date, minutes, now) || (setting.ReminderChangedOnly && !slot.Overridden) {
+						continue
+					}
+					claimed, err := repos.Reminders.Claim(ctx, setting.ClassID, date, slot.SlotIndex, slot.StartTime)
+					if err != nil {
+						return err
+					}
+					if !claimed {
+						continue
+					}
+					event := domain.TimetableEvent{
+						EventType: "SlotReminder",
+						Payload: domain.SlotReminderPayload{
+							ClassID:       setting.ClassID.String(),
+							Date:          date.Format("2006-01-02"),
+							MatrixRoomID:  setting.MatrixRoomID,
+							MinutesBefore: minutes,
+							Changed:       slot.Overridden,
+							Slot:          slotToPayload(slot),
+						},
+					}
+					if err := repos.Outbox.Insert(ctx, event); err != nil {
+						return err
+					}
+					reminded = append(reminded, slot)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, slot := range reminded {
+				slog.InfoContext(ctx, "slot reminder emitted",
+					"class_id", setting.ClassID,
+					"date", date.Format("2006-01-02"),
+					"slot_index", slot.SlotIndex,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// PurgeSlotRecords deletes the reminder and lifecycle event records of
+// dates more than a week before now.
+func (s *TimetableService) PurgeSlotRecords(ctx context.Context, now time.Time) (int64, error) {
+	before := truncateToDateLocal(now).AddDate(0, 0, -slotRecordRetentionDays)
+	var deleted int64
+	err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		reminders, err := repos.Reminders.DeleteBefore(ctx, before)
+		if err != nil {
+			return err
+		}
+		lifecycle, err := repos.Lifecycle.DeleteBefore(ctx, before)
+		deleted = reminders + lifecycle
+		return err
+	})
+	return deleted, err
+}
+
+// reminderDates returns today and, when the lead time reaches past
+// midnight, tomorrow.
+func reminderDates(now time.Time, minutes int) []time.Time {
+	today := truncateToDateLocal(now)
+	dates := []time.Time{today}
+	if horizon := truncateToDateLocal(now.Add(time.Duration(minutes) * time.Minute)); horizon.After(today) {
+		dates = append(dates, horizon)
+	}
+	return dates
+}
+
+func reminderDue(slot domain.Slot, date time.Time, minutes int, now time.Time) bool {
+	if slot.Status == "cancelled" || slot.StartTime.IsZero() {
+		return false
+	}
+	start := slotStart(slot, date)
+	remindAt := start.Add(-time.Duration(minutes) * time.Minute)
+	return !now.Before(remindAt) && now.Before(start)
+}
+
+// slotStart combines date with the slot's start time of day.
+func slotStart(slot domain.Slot, date time.Time) time.Time {
+	local := date.In(time.Local)
+	return time.Date(
+		local.Year(),
+		local.Month(),
+		local.Day(),
+		slot.StartTime.Hour(),
+		slot.StartTime.Minute(),
+		0,
+		0,
+		time.Local,
+	)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+type fakeSettings struct {
+	repository.AnnouncementSettingsRepository
+	settings []domain.AnnouncementSettings
+}
+
+func (f fakeSettings) ListAll(ctx context.Context) ([]domain.AnnouncementSettings, error) {
+	return f.settings, nil
+}
+
+// fakeReminders claims like the Postgres repository, once per class, date,
+// slot index and start time.
+type fakeReminders struct {
+	repository.SlotReminderRepository
+	claimed map[string]bool
+}
+
+func (f fakeReminders) Claim(ctx context.Context, classID uuid.UUID, date time.Time, slotIndex int, startTime time.Time) (bool, error) {
+	key := fmt.Sprintf("%s/%s/%d/%s", classID, date.Format("2006-01-02"), slotIndex, startTime.Format("15:04:05"))
+	if f.claimed[key] {
+		return false, nil
+	}
+	f.claimed[key] = true
+	return true, nil
+}
+
+type fakeOutbox struct {
+	repository.OutboxRepository
+	events *[]domain.TimetableEvent
+}
+
+func (f fakeOutbox) Insert(ctx context.Context, event domain.TimetableEvent) error {
+	*f.events = append(*f.events, event)
+	return nil
+}
+
+func TestReminderDue(t *testing.T) {
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 19, hour, minute, 0, 0, time.Local)
+	}
+	slot := domain.Slot{SlotIndex: 1, StartTime: time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC), Status: "scheduled"}
+	cancelled := slot
+	cancelled.Status = "cancelled"
+	noStart := slot
+	noStart.StartTime = time.Time{}
+
+	tests := []struct {
+		name string
+		slot domain.Slot
+		now  time.Time
+		want bool
+	}{
+		{name: "before the lead time", slot: slot, now: at(8, 44), want: false},
+		{name: "at the lead time", slot: slot, now: at(8, 45), want: true},
+		{name: "just before the start", slot: slot, now: at(8, 59), want: true},
+		{name: "at the start", slot: slot, now: at(9, 0), want: false},
+		{name: "after the start", slot: slot, now: at(9, 30), want: false},
+		{name: "cancelled", slot: cancelled, now: at(8, 50), want: false},
+		{name: "without a start time", slot: noStart, now: at(8, 50), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reminderDue(tt.slot, date, 15, tt.now); got != tt.want {
+				t.Errorf("reminderDue = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReminderDatesPastMidnight(t *testing.T) {
+	now := time.Date(2026, 10, 19, 23, 30, 0, 0, time.Local)
+	today := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	if got := reminderDates(now, 15); len(got) != 1 || !got[0].Equal(today) {
+		t.Errorf("reminderDates(15m) = %v, want only today", got)
+	}
+	if got := reminderDates(now, 60); len(got) != 2 || !got[0].Equal(today) || !got[1].Equal(tomorrow) {
+		t.Errorf("reminderDates(60m) = %v, want today and tomorrow", got)
+	}
+}
+
+func TestEmitDueSlotRemindersDeduplicates(t *testing.T) {
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 19, hour, minute, 0, 0, time.Local)
+	}
+	timeOfDay := func(hour, minute int) time.Time {
+		return time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+	minutes := 15
+	settings := fakeSettings{settings: []domain.AnnouncementSettings{{ClassID: classA, MatrixRoomID: "!room", ReminderMinutes: &minutes}}}
+	defaults := fakeDefaultSlots{slots: []domain.DefaultSlot{
+		{ClassID: classA, SlotIndex: 1, CourseCode: "CS101", StartTime: timeOfDay(9, 0), EndTime: timeOfDay(10, 0)},
+	}}
+	reminders := fakeReminders{claimed: make(map[string]bool)}
+	var events []domain.TimetableEvent
+	repos := repository.TxRepositories{
+		Settings:     settings,
+		DefaultSlots: defaults,
+		Overrides:    fakeOverrides{},
+		Reminders:    reminders,
+		Outbox:       fakeOutbox{events: &events},
+	}
+	service := NewTimetableService(fakeTxManager{repos: repos}, fakeIdentity{}, DefaultPermissions())
+
+	emit := func(now time.Time) {
+		t.Helper()
+		if err := service.EmitDueSlotReminders(context.Background(), now); err != nil {
+			t.Fatalf("EmitDueSlotReminders at %s: %v", now.Format("15:04"), err)
+		}
+	}
+	requireReminders := func(want ...string) {
+		t.Helper()
+		var got []string
+		for _, event := range events {
+			got = append(got, event.Payload.(domain.SlotReminderPayload).Slot.StartTime)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("reminded of slots starting at %v, want %v", got, want)
+		}
+	}
+
+	emit(at(8, 40))
+	requireReminders()
+
+	// The job runs every minute through the lead time but reminds once.
+	emit(at(8, 45))
+	emit(at(8, 46))
+	emit(at(8, 59))
+	requireReminders("09:00")
+
+	// The slot is moved to a later time after its reminder, and is reminded
+	// of again ahead of its new start.
+	later, laterEnd := timeOfDay(9, 30), timeOfDay(10, 30)
+	repos.Overrides = fakeOverrides{overrides: []domain.DailyOverride{
+		{ClassID: classA, Date: date, SlotIndex: 1, CourseCode: "CS101", StartTime: &later, EndTime: &laterEnd, Status: "scheduled"},
+	}}
+	service.txManager = fakeTxManager{repos: repos}
+	emit(at(9, 10))
+	requireReminders("09:00")
+	emit(at(9, 15))
+	emit(at(9, 20))
+	requireReminders("09:00", "09:30")
+}
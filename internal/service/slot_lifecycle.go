@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+const (
+	EventSlotStarted = "SlotStarted"
+	EventSlotEnded   = "SlotEnded"
+)
+
+// EmitDueSlotLifecycleEvents writes SlotStarted once a slot of today has
+// started and SlotEnded once it has ended, for every class with slots
+// today. Slots are resolved on every call, so an override made before an
+// event fires moves it, and cancelling a slot before it starts suppresses
+// both events. SlotStarted is not emitted for a slot that already ended, and
+// SlotEnded only follows a SlotStarted, so consumers always see pairs.
+func (s *TimetableService) EmitDueSlotLifecycleEvents(ctx context.Context, now time.Time) error {
+	date := truncateToDateLocal(now)
+
+	var classIDs []uuid.UUID
+	err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		withDefaults, err := repos.DefaultSlots.ListClassIDsByWeekday(ctx, weekdayNumber(date))
+		if err != nil {
+			return err
+		}
+		withOverrides, err := repos.Overrides.ListClassIDsByDate(ctx, date)
+		if err != nil {
+			return err
+		}
+		classIDs = append(withDefaults, withOverrides...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(classIDs, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	classIDs = slices.Compact(classIDs)
+
+	for _, classID := range classIDs {
+		var emitted []domain.SlotLifecyclePayload
+		err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+			emitted = nil
+			slots, err := s.resolveTimetableWithRepos(ctx, repos, classID, date)
+			if err != nil {
+				return err
+			}
+			recorded, err := repos.Lifecycle.ListByDate(ctx, classID, date)
+			if err != nil {
+				return err
+			}
+			for _, slot := range slots {
+				eventType, at, due := slotLifecycleDue(slot, date, recorded[slot.SlotIndex], now)
+				if !due {
+					continue
+				}
+				claimed, err := repos.Lifecycle.Claim(ctx, classID, date, slot.SlotIndex, eventType)
+				if err != nil {
+					return err
+				}
+				if !claimed {
+					continue
+				}
+				payload := slotLifecyclePayload(classID, date, slot, at)
+				if err := repos.Outbox.Insert(ctx, domain.TimetableEvent{EventType: eventType, Payload: payload}); err != nil {
+					return err
+				}
+				emitted = append(emitted, payload)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, payload := range emitted {
+			slog.DebugContext(ctx, "slot lifecycle event emitted", "class_id", classID, "slot_id", payload.SlotID, "at", payload.At)
+		}
+	}
+	return nil
+}
+
+// slotLifecycleDue returns the event due for the slot given the events
+// already emitted for it, if any.
+func slotLifecycleDue(slot domain.Slot, date time.Time, emitted []string, now time.Time) (string, time.Time, bool) {
+	if slot.StartTime.IsZero() || slot.EndTime.IsZero() {
+		return "", time.Time{}, false
+	}
+	start := slotStart(slot, date)
+	end := slotEnd(slot, date)
+	started := slices.Contains(emitted, EventSlotStarted)
+
+	switch {
+	case !started:
+		if slot.Status == "cancelled" || now.Before(start) || !now.Before(end) {
+			return "", time.Time{}, false
+		}
+		return EventSlotStarted, start, true
+	case !slices.Contains(emitted, EventSlotEnded):
+		// A slot cancelled or cut short after it started still ends, at
+		// its resolved end or right away when that has passed.
+		if now.Before(end) && slot.Status != "cancelled" {
+			return "", time.Time{}, false
+		}
+		if now.Before(end) {
+			return EventSlotEnded, now, true
+		}
+		return EventSlotEnded, end, true
+	default:
+		return "", time.Time{}, false
+	}
+}
+
+func slotLifecyclePayload(classID uuid.UUID, date time.Time, slot domain.Slot, at time.Time) domain.SlotLifecyclePayload {
+	return domain.SlotLifecyclePayload{
+		SlotID:     slotOccurrenceID(classID, date, slot.SlotIndex).String(),
+		ClassID:    classID.String(),
+		Date:       date.Format("2006-01-02"),
+		SlotIndex:  slot.SlotIndex,
+		CourseCode: slot.CourseCode,
+		Venue:      slot.Venue,
+		FacultyID:  formatOptionalUUID(slot.FacultyID),
+		StartTime:  formatTime(slot.StartTime),
+		EndTime:    formatTime(slot.EndTime),
+		Status:     slot.Status,
+		At:         at.Format(time.RFC3339),
+	}
+}
+
+// slotOccurrenceID derives a stable ID for a slot index of a class on a
+// date, so that events about the same slot can be correlated.
+func slotOccurrenceID(classID uuid.UUID, date time.Time, slotIndex int) uuid.UUID {
+	return uuid.NewSHA1(classID, []byte(date.Format("2006-01-02")+"/"+strconv.Itoa(slotIndex)))
+}
+
+func slotEnd(slot domain.Slot, date time.Time) time.Time {
+	local := date.In(time.Local)
+	return time.Date(local.Year(), local.Month(), local.Day(), slot.EndTime.Hour(), slot.EndTime.Minute(), 0, 0, time.Local)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"service-timetable/internal/domain"
+)
+
+func TestSlotLifecycleDue(t *testing.T) {
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 19, hour, minute, 0, 0, time.Local)
+	}
+	slot := domain.Slot{
+		SlotIndex: 1,
+		StartTime: time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC),
+		Status:    "scheduled",
+	}
+	cancelled := slot
+	cancelled.Status = "cancelled"
+	noTimes := slot
+	noTimes.EndTime = time.Time{}
+
+	started := []string{EventSlotStarted}
+	ended := []string{EventSlotStarted, EventSlotEnded}
+
+	tests := []struct {
+		name      string
+		slot      domain.Slot
+		emitted   []string
+		now       time.Time
+		wantEvent string
+		wantAt    time.Time
+	}{
+		{name: "before the start", slot: slot, now: at(8, 59)},
+		{name: "at the start", slot: slot, now: at(9, 0), wantEvent: EventSlotStarted, wantAt: at(9, 0)},
+		{name: "during the slot", slot: slot, now: at(9, 30), wantEvent: EventSlotStarted, wantAt: at(9, 0)},
+		{name: "during the slot after it started", slot: slot, emitted: started, now: at(9, 30)},
+		{name: "at the end", slot: slot, emitted: started, now: at(10, 0), wantEvent: EventSlotEnded, wantAt: at(10, 0)},
+		{name: "after the slot", slot: slot, emitted: started, now: at(11, 0), wantEvent: EventSlotEnded, wantAt: at(10, 0)},
+		{name: "after the slot after it ended", slot: slot, emitted: ended, now: at(11, 0)},
+		// A slot missed entirely, e.g. while no replica was leader, never
+		// starts late.
+		{name: "after the slot without a start", slot: slot, now: at(11, 0)},
+		{name: "cancelled before the start", slot: cancelled, now: at(8, 59)},
+		{name: "cancelled during the slot", slot: cancelled, now: at(9, 30)},
+		{name: "cancelled after it started", slot: cancelled, emitted: started, now: at(9, 30), wantEvent: EventSlotEnded, wantAt: at(9, 30)},
+		{name: "cancelled after it ended", slot: cancelled, emitted: ended, now: at(9, 30)},
+		{name: "without an end time", slot: noTimes, now: at(9, 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, eventAt, due := slotLifecycleDue(tt.slot, date, tt.emitted, tt.now)
+			if due != (tt.wantEvent != "") {
+				t.Fatalf("due = %v with %q, want event %q", due, event, tt.wantEvent)
+			}
+			if event != tt.wantEvent || !eventAt.Equal(tt.wantAt) {
+				t.Errorf("event = %q at %s, want %q at %s", event, eventAt, tt.wantEvent, tt.wantAt)
+			}
+		})
+	}
+}
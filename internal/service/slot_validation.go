@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"service-timetable/internal/domain"
+)
+
+type SlotConflictError struct {
+	Slot      domain.Slot
+	Conflicts []domain.Slot
+}
+
+func (e *SlotConflictError) Error() string {
+	conflicts := make([]string, 0, len(e.Conflicts))
+	for _, conflict := range e.Conflicts {
+		conflicts = append(conflicts, fmt.Sprintf(
+			"%s %s-%s",
+			conflict.CourseCode,
+			formatTime(conflict.StartTime),
+			formatTime(conflict.EndTime),
+		))
+	}
+	return fmt.Sprintf(
+		"slot %s-%s overlaps %s",
+		formatTime(e.Slot.StartTime),
+		formatTime(e.Slot.EndTime),
+		strings.Join(conflicts, ", "),
+	)
+}
+
+func (e *SlotConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func validateTimeRange(start time.Time, end time.Time) error {
+	if secondOfDay(end) <= secondOfDay(start) {
+		return invalidField("end_time", "must be after start_time")
+	}
+	return nil
+}
+
+func validateOptionalTimeRange(start *time.Time, end *time.Time) error {
+	if start == nil || end == nil {
+		return nil
+	}
+	return validateTimeRange(*start, *end)
+}
+
+// validateSlotPlacement checks a candidate slot against the other slots of the
+// same day. Cancelled slots never conflict, and the candidate's own slot index
+// is skipped so that rewriting a slot in place is allowed.
+func validateSlotPlacement(candidate domain.Slot, day []domain.Slot) error {
+	if candidate.Status == "cancelled" {
+		return nil
+	}
+	if err := validateTimeRange(candidate.StartTime, candidate.EndTime); err != nil {
+		return err
+	}
+
+	var conflicts []domain.Slot
+	for _, other := range day {
+		if other.SlotIndex == candidate.SlotIndex || other.Status == "cancelled" {
+			continue
+		}
+		if slotsOverlap(candidate, other) {
+			conflicts = append(conflicts, other)
+		}
+	}
+	if len(conflicts) > 0 {
+		return &SlotConflictError{Slot: candidate, Conflicts: conflicts}
+	}
+	return nil
+}
+
+func slotsOverlap(a domain.Slot, b domain.Slot) bool {
+	return secondOfDay(a.StartTime) < secondOfDay(b.EndTime) &&
+		secondOfDay(b.StartTime) < secondOfDay(a.EndTime)
+}
+
+func secondOfDay(t time.Time) int {
+	return t.Hour()*3600 + t.Minute()*60 + t.Second()
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"service-timetable/internal/domain"
+)
+
+func timeOfDay(hour, minute int) time.Time {
+	return time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func slotAt(index int, start, end time.Time) domain.Slot {
+	return domain.Slot{SlotIndex: index, CourseCode: "CS101", StartTime: start, EndTime: end, Status: "scheduled"}
+}
+
+func TestValidateTimeRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   time.Time
+		end     time.Time
+		wantErr bool
+	}{
+		{name: "end after start", start: timeOfDay(9, 0), end: timeOfDay(10, 0)},
+		{name: "one second long", start: timeOfDay(9, 0), end: timeOfDay(9, 0).Add(time.Second)},
+		{name: "start equal to end", start: timeOfDay(9, 0), end: timeOfDay(9, 0), wantErr: true},
+		{name: "end before start", start: timeOfDay(10, 0), end: timeOfDay(9, 0), wantErr: true},
+		// Only the time of day counts, not the date the times carry.
+		{name: "end on a later date but earlier", start: timeOfDay(10, 0), end: timeOfDay(9, 0).AddDate(0, 0, 1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTimeRange(tt.start, tt.end)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("validateTimeRange error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "end_time" {
+				t.Errorf("error = %v, want a validation error on end_time", err)
+			}
+		})
+	}
+}
+
+func TestSlotsOverlap(t *testing.T) {
+	tests := []struct {
+		name string
+		a    domain.Slot
+		b    domain.Slot
+		want bool
+	}{
+		{name: "disjoint", a: slotAt(1, timeOfDay(9, 0), timeOfDay(10, 0)), b: slotAt(2, timeOfDay(11, 0), timeOfDay(12, 0))},
+		{name: "touching", a: slotAt(1, timeOfDay(9, 0), timeOfDay(10, 0)), b: slotAt(2, timeOfDay(10, 0), timeOfDay(11, 0))},
+		{name: "overlapping", a: slotAt(1, timeOfDay(9, 0), timeOfDay(10, 0)), b: slotAt(2, timeOfDay(9, 59), timeOfDay(11, 0)), want: true},
+		{name: "contained", a: slotAt(1, timeOfDay(9, 0), timeOfDay(12, 0)), b: slotAt(2, timeOfDay(10, 0), timeOfDay(11, 0)), want: true},
+		{name: "identical", a: slotAt(1, timeOfDay(9, 0), timeOfDay(10, 0)), b: slotAt(2, timeOfDay(9, 0), timeOfDay(10, 0)), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slotsOverlap(tt.a, tt.b); got != tt.want {
+				t.Errorf("slotsOverlap(a, b) = %v, want %v", got, tt.want)
+			}
+			if got := slotsOverlap(tt.b, tt.a); got != tt.want {
+				t.Errorf("slotsOverlap(b, a) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSlotPlacement(t *testing.T) {
+	cancelled := slotAt(3, timeOfDay(12, 0), timeOfDay(13, 0))
+	cancelled.Status = "cancelled"
+	day := []domain.Slot{
+		slotAt(1, timeOfDay(9, 0), timeOfDay(10, 0)),
+		slotAt(2, timeOfDay(10, 0), timeOfDay(11, 0)),
+		cancelled,
+	}
+
+	tests := []struct {
+		name          string
+		candidate     domain.Slot
+		wantInvalid   bool
+		wantConflicts []int
+	}{
+		{name: "free time", candidate: slotAt(4, timeOfDay(14, 0), timeOfDay(15, 0))},
+		{name: "touching both neighbours", candidate: slotAt(4, timeOfDay(11, 0), timeOfDay(12, 0))},
+		{name: "overlapping one slot", candidate: slotAt(4, timeOfDay(10, 30), timeOfDay(11, 30)), wantConflicts: []int{2}},
+		{name: "overlapping two slots", candidate: slotAt(4, timeOfDay(9, 30), timeOfDay(10, 30)), wantConflicts: []int{1, 2}},
+		{name: "moved onto itself", candidate: slotAt(1, timeOfDay(9, 0), timeOfDay(10, 0))},
+		{name: "moved within its own time", candidate: slotAt(1, timeOfDay(9, 15), timeOfDay(9, 45))},
+		{name: "moved onto another slot", candidate: slotAt(1, timeOfDay(10, 0), timeOfDay(11, 0)), wantConflicts: []int{2}},
+		{name: "over a cancelled slot", candidate: slotAt(4, timeOfDay(12, 0), timeOfDay(13, 0))},
+		{name: "start equal to end", candidate: slotAt(4, timeOfDay(14, 0), timeOfDay(14, 0)), wantInvalid: true},
+		{name: "cancelled candidate", candidate: domain.Slot{SlotIndex: 4, StartTime: timeOfDay(9, 0), EndTime: timeOfDay(9, 0), Status: "cancelled"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSlotPlacement(tt.candidate, day)
+
+			var conflictErr *SlotConflictError
+			switch {
+			case tt.wantInvalid:
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Fatalf("error = %v, want %v", err, ErrInvalidInput)
+				}
+			case len(tt.wantConflicts) > 0:
+				if !errors.As(err, &conflictErr) || !errors.Is(err, ErrConflict) {
+					t.Fatalf("error = %v, want a slot conflict", err)
+				}
+				var got []int
+				for _, conflict := range conflictErr.Conflicts {
+					got = append(got, conflict.SlotIndex)
+				}
+				if len(got) != len(tt.wantConflicts) {
+					t.Fatalf("conflicting slots = %v, want %v", got, tt.wantConflicts)
+				}
+				for i := range got {
+					if got[i] != tt.wantConflicts[i] {
+						t.Fatalf("conflicting slots = %v, want %v", got, tt.wantConflicts)
+					}
+				}
+			case err != nil:
+				t.Fatalf("validateSlotPlacement: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+)
+
+const (
+	maxTemplateLength = 4000
+	// Templates are user input executed on every announcement, so their cost
+	// is bounded: ranges may only be nested maxTemplateRangeDepth deep and
+	// numbers, including printf widths, may not exceed maxTemplateNumber. The
+	// output is capped at maxMessageBytes and rendering at
+	// templateRenderTimeout.
+	maxTemplateRangeDepth = 2
+	maxTemplateNumber     = 1000
+	maxMessageBytes       = 16 << 10
+	templateRenderTimeout = 100 * time.Millisecond
+)
+
+var errMessageTooLong = fmt.Errorf("message is over %d bytes", maxMessageBytes)
+
+// formatWidth matches the width and precision of a printf verb.
+var formatWidth = regexp.MustCompile(`%[-+# 0]*(?:\[\d+\])?(\d*)(?:\.(?:\[\d+\])?(\d*))?`)
+
+// templateData builds the variables templates are rendered with in locale:
+// date, long_date, weekday, slots, changes and updated_by. Slots and changes
+// are lists of slot_index, course_code, start_time, end_time, venue and
+// status.
+func templateData(locale string, date time.Time, slots []domain.Slot, changes []domain.Slot, updatedBy string) map[string]any {
+	format := formatFor(locale)
+	return map[string]any{
+		"date":       date.Format("2006-01-02"),
+		"long_date":  format.formatLongDate(date),
+		"weekday":    format.weekday(date),
+		"slots":      templateSlots(slots),
+		"changes":    templateSlots(changes),
+		"updated_by": updatedBy,
+	}
+}
+
+func templateSlots(slots []domain.Slot) []map[string]any {
+	result := make([]map[string]any, 0, len(slots))
+	for _, slot := range slots {
+		result = append(result, map[string]any{
+			"slot_index":  slot.SlotIndex,
+			"course_code": slot.CourseCode,
+			"start_time":  formatTime(slot.StartTime),
+			"end_time":    formatTime(slot.EndTime),
+			"venue":       slot.Venue,
+			"status":      slot.Status,
+		})
+	}
+	return result
+}
+
+// changedSlots returns the slots an override changed for their date.
+func changedSlots(slots []domain.Slot) []domain.Slot {
+	var changed []domain.Slot
+	for _, slot := range slots {
+		if slot.Overridden {
+			changed = append(changed, slot)
+		}
+	}
+	return changed
+}
+
+func renderTemplate(ctx context.Context, text string, data map[string]any) (string, error) {
+	tmpl, err := template.New("template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	for _, defined := range tmpl.Templates() {
+		if defined.Tree == nil {
+			continue
+		}
+		if err := checkTemplateNode(defined.Tree.Root, 0); err != nil {
+			return "", err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, templateRenderTimeout)
+	defer cancel()
+	out := &messageWriter{ctx: ctx, remaining: maxMessageBytes}
+	done := make(chan error, 1)
+	go func() {
+		done <- tmpl.Execute(out, data)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return "", err
+		}
+		return out.message.String(), nil
+	case <-ctx.Done():
+		// The writer fails from now on, which stops the execution at its next
+		// write; checkTemplateNode bounds the work until then. Waiting for it
+		// keeps renders from running on after the call.
+		<-done
+		return "", fmt.Errorf("rendering did not finish within %s: %w", templateRenderTimeout, ctx.Err())
+	}
+}
+
+// checkTemplateNode rejects the constructs that make a template expensive to
+// run: ranges over numbers, deeply nested ranges, large numbers, calls of
+// other templates and wide printf verbs.
+func checkTemplateNode(node parse.Node, rangeDepth int) error {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return nil
+		}
+		for _, child := range node.Nodes {
+			if err := checkTemplateNode(child, rangeDepth); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return checkTemplateNode(node.Pipe, rangeDepth)
+	case *parse.PipeNode:
+		if node == nil {
+			return nil
+		}
+		for _, command := range node.Cmds {
+			if err := checkTemplateNode(command, rangeDepth); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			if err := checkTemplateNode(arg, rangeDepth); err != nil {
+				return err
+			}
+		}
+	case *parse.ChainNode:
+		return checkTemplateNode(node.Node, rangeDepth)
+	case *parse.IfNode:
+		return checkBranch(&node.BranchNode, rangeDepth)
+	case *parse.WithNode:
+		return checkBranch(&node.BranchNode, rangeDepth)
+	case *parse.RangeNode:
+		if rangeDepth >= maxTemplateRangeDepth {
+			return fmt.Errorf("line %d: range must not be nested more than %d deep", node.Line, maxTemplateRangeDepth)
+		}
+		if cmds := node.Pipe.Cmds; len(cmds) == 1 && len(cmds[0].Args) == 1 {
+			if _, ok := cmds[0].Args[0].(*parse.NumberNode); ok {
+				return fmt.Errorf("line %d: range must not be over a number", node.Line)
+			}
+		}
+		if err := checkTemplateNode(node.Pipe, rangeDepth); err != nil {
+			return err
+		}
+		if err := checkTemplateNode(node.List, rangeDepth+1); err != nil {
+			return err
+		}
+		return checkTemplateNode(node.ElseList, rangeDepth)
+	case *parse.TemplateNode:
+		return fmt.Errorf("line %d: must not call other templates", node.Line)
+	case *parse.NumberNode:
+		if !node.IsFloat || math.Abs(node.Float64) > maxTemplateNumber {
+			return fmt.Errorf("number %s must be between -%d and %d", node.Text, maxTemplateNumber, maxTemplateNumber)
+		}
+	case *parse.StringNode:
+		for _, match := range formatWidth.FindAllStringSubmatch(node.Text, -1) {
+			for _, digits := range match[1:] {
+				if width, err := strconv.Atoi(digits); digits != "" && (err != nil || width > maxTemplateNumber) {
+					return fmt.Errorf("format %s must not be wider than %d", match[0], maxTemplateNumber)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func checkBranch(node *parse.BranchNode, rangeDepth int) error {
+	if err := checkTemplateNode(node.Pipe, rangeDepth); err != nil {
+		return err
+	}
+	if err := checkTemplateNode(node.List, rangeDepth); err != nil {
+		return err
+	}
+	return checkTemplateNode(node.ElseList, rangeDepth)
+}
+
+// messageWriter collects a rendered message. It fails once the message would
+// exceed its remaining bytes or ctx is done, which aborts the execution.
+type messageWriter struct {
+	ctx       context.Context
+	message   strings.Builder
+	remaining int
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if len(p) > w.remaining {
+		return 0, errMessageTooLong
+	}
+	w.remaining -= len(p)
+	return w.message.Write(p)
+}
+
+// validateTemplate returns why text is not a valid template, or "" when it
+// is. Rendering against a sample day catches unknown variables as well as
+// syntax errors.
+func validateTemplate(ctx context.Context, text string) string {
+	if text == "" {
+		return "is required"
+	}
+	if len(text) > maxTemplateLength {
+		return fmt.Sprintf("must be at most %d characters", maxTemplateLength)
+	}
+	start := time.Date(2000, 1, 1, 9, 0, 0, 0, time.Local)
+	slot := domain.Slot{
+		SlotIndex:  1,
+		CourseCode: "CS101",
+		StartTime:  start,
+		EndTime:    start.Add(50 * time.Minute),
+		Venue:      "A-101",
+		Status:     "replaced",
+		Overridden: true,
+	}
+	data := templateData(defaultLocale, truncateToDateLocal(start), []domain.Slot{slot}, []domain.Slot{slot}, uuid.Nil.String())
+	if _, err := renderTemplate(ctx, text, data); err != nil {
+		return "is not a valid template: " + err.Error()
+	}
+	return ""
+}
+
+// renderMessages renders the template of every locale for an event. Templates
+// saved before they were validated may fail to render; their message is left
+// empty.
+func renderMessages(
+	ctx context.Context,
+	classID uuid.UUID,
+	templates map[string]string,
+	date time.Time,
+	slots []domain.Slot,
+	changes []domain.Slot,
+	updatedBy string,
+) map[string]string {
+	messages := make(map[string]string, len(templates))
+	for locale, text := range templates {
+		message, err := renderTemplate(ctx, text, templateData(locale, date, slots, changes, updatedBy))
+		if err != nil {
+			slog.WarnContext(ctx, "template rendering failed", "class_id", classID, "locale", locale, "error", err)
+		}
+		messages[locale] = message
+	}
+	return messages
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"service-timetable/internal/domain"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		problem string
+	}{
+		{name: "valid", text: `{{.weekday}}: {{range .slots}}{{.course_code}} {{printf "%-8s" .venue}}{{end}}`},
+		{name: "nested range", text: `{{range .slots}}{{range $.changes}}{{.venue}}{{end}}{{end}}`},
+		{name: "range over a number", text: `{{range 5}}x{{end}}`, problem: "range must not be over a number"},
+		{name: "range over a huge number", text: `{{range 200000000}}{{end}}`, problem: "range must not be over a number"},
+		{name: "huge number", text: `{{$n := 200000000}}{{range $n}}{{end}}`, problem: "number 200000000"},
+		{name: "huge float", text: `{{if lt 1e9 2}}x{{end}}`, problem: "number 1e9"},
+		{name: "range too deep", text: `{{range .slots}}{{range $.slots}}{{range $.slots}}{{end}}{{end}}{{end}}`, problem: "nested more than 2 deep"},
+		{name: "range too deep in a branch", text: `{{range .slots}}{{if .venue}}{{with $.slots}}{{range .}}{{range $.slots}}{{end}}{{end}}{{end}}{{end}}{{end}}`, problem: "nested more than 2 deep"},
+		{name: "template call", text: `{{define "a"}}{{template "a"}}{{end}}{{template "a"}}`, problem: "must not call other templates"},
+		{name: "wide printf", text: `{{printf "%0100000000d" 1}}`, problem: "must not be wider than 1000"},
+		{name: "precise printf", text: `{{printf "%.2000f" 1.5}}`, problem: "must not be wider than 1000"},
+		{name: "wide printf in a variable", text: `{{$f := "%0100000000d"}}{{printf $f 1}}`, problem: "must not be wider than 1000"},
+		{name: "unknown variable", text: `{{.room}}`, problem: "is not a valid template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			problem := validateTemplate(context.Background(), tt.text)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("validateTemplate took %s", elapsed)
+			}
+			if tt.problem == "" {
+				if problem != "" {
+					t.Fatalf("validateTemplate = %q, want no problem", problem)
+				}
+				return
+			}
+			if !strings.Contains(problem, tt.problem) {
+				t.Errorf("validateTemplate = %q, want it to mention %q", problem, tt.problem)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateCapsOutput(t *testing.T) {
+	slots := make([]domain.Slot, 30)
+	for i := range slots {
+		slots[i] = domain.Slot{SlotIndex: i + 1, CourseCode: "CS101", Venue: "A-101"}
+	}
+	data := templateData(defaultLocale, time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local), slots, nil, "")
+
+	_, err := renderTemplate(context.Background(), `{{range .slots}}{{range $.slots}}{{printf "%1000s" .venue}}{{end}}{{end}}`, data)
+	if !errors.Is(err, errMessageTooLong) {
+		t.Fatalf("renderTemplate error = %v, want %v", err, errMessageTooLong)
+	}
+
+	message, err := renderTemplate(context.Background(), `{{len .slots}} slots`, data)
+	if err != nil || message != "30 slots" {
+		t.Fatalf("renderTemplate = %q, %v; want %q", message, err, "30 slots")
+	}
+}
+
+func TestRenderTemplateStopsAtDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	data := templateData(defaultLocale, time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local), nil, nil, "")
+
+	if _, err := renderTemplate(ctx, `{{.date}}`, data); !errors.Is(err, context.Canceled) {
+		t.Fatalf("renderTemplate error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRenderTemplateStopsRenderingAfterDeadline(t *testing.T) {
+	// Each slot takes longer to render than the whole render may.
+	var calls, returned atomic.Int32
+	data := map[string]any{
+		"slots": make([]int, 100),
+		"slow": func() string {
+			calls.Add(1)
+			time.Sleep(2 * templateRenderTimeout)
+			returned.Add(1)
+			return "x"
+		},
+	}
+
+	_, err := renderTemplate(context.Background(), `{{range .slots}}{{call $.slow}}{{end}}`, data)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("renderTemplate error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	// The render stopped at the write after the deadline, before returning.
+	if calls.Load() != 1 || returned.Load() != 1 {
+		t.Errorf("slow was called %d times and returned %d times, want once each", calls.Load(), returned.Load())
+	}
+	time.Sleep(3 * templateRenderTimeout)
+	if calls.Load() != 1 {
+		t.Errorf("rendering went on after renderTemplate returned: slow was called %d times", calls.Load())
+	}
+}
+
+func TestTemplatesAreCheckedAfterAuthorization(t *testing.T) {
+	cr := userWith(role("cr", &classA, ""))
+	service := newPermissionTestService(fakeIdentity{cr.ID: cr}, fakeDelegations{})
+	const expensive = `{{range 200000000}}{{end}}`
+
+	_, err := service.PreviewTemplate(context.Background(), cr.ID, classA, time.Now(), "en", expensive)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("PreviewTemplate error = %v, want %v", err, ErrUnauthorized)
+	}
+	err = service.UpdateAnnouncementSettings(context.Background(), cr.ID, classA, "", "en", expensive, nil, nil, false, nil)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("UpdateAnnouncementSettings error = %v, want %v", err, ErrUnauthorized)
+	}
+}
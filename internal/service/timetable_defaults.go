@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+func (s *TimetableService) UpsertDefaultSlot(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	weekday int,
+	courseCode string,
+	startTime time.Time,
+	endTime time.Time,
+	venue string,
+	facultyID *uuid.UUID,
+) error {
+	return s.idempotent(ctx, requesterID, nil, func(ctx context.Context) error {
+		return s.upsertDefaultSlot(ctx, requesterID, classID, weekday, courseCode, startTime, endTime, venue, facultyID)
+	})
+}
+
+func (s *TimetableService) upsertDefaultSlot(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	weekday int,
+	courseCode string,
+	startTime time.Time,
+	endTime time.Time,
+	venue string,
+	facultyID *uuid.UUID,
+) error {
+	var invalid ValidationError
+	if weekday < 1 || weekday > 7 {
+		invalid.Add("weekday", "must be between 1 and 7")
+	}
+	if courseCode == "" {
+		invalid.Add("course_code", "is required")
+	}
+	if venue == "" {
+		invalid.Add("venue", "is required")
+	}
+	if err := invalid.Err(); err != nil {
+		return err
+	}
+	if err := validateTimeRange(startTime, endTime); err != nil {
+		return err
+	}
+
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return err
+	}
+
+	slot := domain.DefaultSlot{
+		ClassID:    classID,
+		Weekday:    weekday,
+		CourseCode: courseCode,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Venue:      venue,
+		FacultyID:  facultyID,
+	}
+
+	return s.withIdempotentTx(ctx, requesterID, nil, func(ctx context.Context, repos repository.TxRepositories) error {
+		// Bumping the revision first locks it, which serializes writers of the
+		// weekday's defaults and changes the version of every day it covers.
+		if _, err := repos.Revisions.Bump(ctx, classID, weekday); err != nil {
+			return err
+		}
+		defaults, err := repos.DefaultSlots.ListByWeekday(ctx, classID, weekday)
+		if err != nil {
+			return err
+		}
+
+		// Default slots are keyed by start time, so an existing row with the same
+		// start is the one being rewritten, keeps its slot index and is left out
+		// of the overlap check.
+		slot := slot
+		resource := Resource{ClassID: classID, CourseCodes: []string{courseCode}}
+		candidate := defaultToSlot(slot)
+		day := make([]domain.Slot, 0, len(defaults))
+		for _, def := range defaults {
+			if secondOfDay(def.StartTime) == secondOfDay(startTime) {
+				resource.CourseCodes = append(resource.CourseCodes, def.CourseCode)
+				slot.SlotIndex = def.SlotIndex
+				continue
+			}
+			day = append(day, defaultToSlot(def))
+		}
+		if err := s.authorize(user, CapEditDefaults, resource); err != nil {
+			return err
+		}
+		if err := validateSlotPlacement(candidate, day); err != nil {
+			return err
+		}
+
+		if slot.SlotIndex == 0 {
+			if slot.SlotIndex, err = repos.Revisions.NextSlotIndex(ctx, classID, weekday); err != nil {
+				return err
+			}
+		}
+		if err := repos.DefaultSlots.Upsert(ctx, slot); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, repos, requesterID, AuditDefaultSlotUpsert, classID, nil, map[string]any{
+			"weekday":     weekday,
+			"slot_index":  slot.SlotIndex,
+			"course_code": courseCode,
+			"start_time":  formatTime(startTime),
+			"end_time":    formatTime(endTime),
+			"venue":       venue,
+			"faculty_id":  facultyID,
+		})
+	})
+}
+
+func (s *TimetableService) DeleteDefaultSlot(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	weekday int,
+	startTime time.Time,
+) error {
+	return s.idempotent(ctx, requesterID, nil, func(ctx context.Context) error {
+		return s.deleteDefaultSlot(ctx, requesterID, classID, weekday, startTime)
+	})
+}
+
+func (s *TimetableService) deleteDefaultSlot(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	weekday int,
+	startTime time.Time,
+) error {
+	if weekday < 1 || weekday > 7 {
+		return invalidField("weekday", "must be between 1 and 7")
+	}
+
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return err
+	}
+
+	return s.withIdempotentTx(ctx, requesterID, nil, func(ctx context.Context, repos repository.TxRepositories) error {
+		if _, err := repos.Revisions.Bump(ctx, classID, weekday); err != nil {
+			return err
+		}
+		defaults, err := repos.DefaultSlots.ListByWeekday(ctx, classID, weekday)
+		if err != nil {
+			return err
+		}
+		resource := Resource{ClassID: classID}
+		for _, def := range defaults {
+			if secondOfDay(def.StartTime) == secondOfDay(startTime) {
+				resource.CourseCodes = append(resource.CourseCodes, def.CourseCode)
+			}
+		}
+		if err := s.authorize(user, CapEditDefaults, resource); err != nil {
+			return err
+		}
+
+		deleted, err := repos.DefaultSlots.Delete(ctx, classID, weekday, startTime)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return ErrNotFound
+		}
+		return s.recordAudit(ctx, repos, requesterID, AuditDefaultSlotDelete, classID, nil, map[string]any{
+			"weekday":    weekday,
+			"start_time": formatTime(startTime),
+		})
+	})
+}
+
+func defaultToSlot(def domain.DefaultSlot) domain.Slot {
+	return domain.Slot{
+		SlotIndex:  def.SlotIndex,
+		CourseCode: def.CourseCode,
+		StartTime:  def.StartTime,
+		EndTime:    def.EndTime,
+		Venue:      def.Venue,
+		FacultyID:  def.FacultyID,
+		Status:     "scheduled",
+	}
+}
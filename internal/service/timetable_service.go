@@ -3,20 +3,19 @@ package service
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"log/slog"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 
 	"service-timetable/internal/domain"
+	"service-timetable/internal/metrics"
 	"service-timetable/internal/repository"
-)
-
-var (
-	ErrInvalidInput = errors.New("invalid input")
-	ErrUnauthorized = errors.New("unauthorized")
-	ErrNotFound     = errors.New("not found")
-	ErrConflict     = errors.New("conflict")
+	"service-timetable/internal/tracing"
 )
 
 type IdentityClient interface {
@@ -26,24 +25,29 @@ type IdentityClient interface {
 type IdentityUser struct {
 	ID    uuid.UUID
 	Roles []IdentityRole
+	// APIKey is set when the requester is an API key rather than a person.
+	APIKey *APIKeyGrant
 }
 
 type IdentityRole struct {
-	Name    string
-	ClassID *uuid.UUID
+	Name       string
+	ClassID    *uuid.UUID
+	CourseCode string
 }
 
 type TimetableService struct {
-	txManager repository.TxManager
-	identity  IdentityClient
-	clock     func() time.Time
+	txManager   repository.TxManager
+	identity    IdentityClient
+	permissions *Permissions
+	clock       func() time.Time
 }
 
-func NewTimetableService(txManager repository.TxManager, identity IdentityClient) *TimetableService {
+func NewTimetableService(txManager repository.TxManager, identity IdentityClient, permissions *Permissions) *TimetableService {
 	return &TimetableService{
-		txManager: txManager,
-		identity:  identity,
-		clock:     time.Now,
+		txManager:   txManager,
+		identity:    identity,
+		permissions: permissions,
+		clock:       time.Now,
 	}
 }
 
@@ -56,8 +60,11 @@ func (s *TimetableService) UpdateTodayOverride(
 	startTime *time.Time,
 	endTime *time.Time,
 	venue string,
+	facultyID *uuid.UUID,
 	status string,
-) error {
+	bypassReason string,
+	expectedVersion *int64,
+) (int64, error) {
 	date := truncateToDateLocal(s.clock())
 	return s.CreateDailyOverride(
 		ctx,
@@ -69,7 +76,10 @@ func (s *TimetableService) UpdateTodayOverride(
 		startTime,
 		endTime,
 		venue,
+		facultyID,
 		status,
+		bypassReason,
+		expectedVersion,
 	)
 }
 
@@ -83,30 +93,67 @@ func (s *TimetableService) CreateDailyOverride(
 	startTime *time.Time,
 	endTime *time.Time,
 	venue string,
+	facultyID *uuid.UUID,
+	status string,
+	bypassReason string,
+	expectedVersion *int64,
+) (int64, error) {
+	var dayVersion int64
+	err := s.idempotent(ctx, requesterID, &dayVersion, func(ctx context.Context) error {
+		var err error
+		dayVersion, err = s.createDailyOverride(ctx, requesterID, classID, date, slotIndex, courseCode, startTime, endTime, venue, facultyID, status, bypassReason, expectedVersion)
+		return err
+	})
+	return dayVersion, err
+}
+
+func (s *TimetableService) createDailyOverride(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	date time.Time,
+	slotIndex int,
+	courseCode string,
+	startTime *time.Time,
+	endTime *time.Time,
+	venue string,
+	facultyID *uuid.UUID,
 	status string,
-) error {
-	if slotIndex <= 0 || !isValidStatus(status) {
-		return ErrInvalidInput
+	bypassReason string,
+	expectedVersion *int64,
+) (int64, error) {
+	var invalid ValidationError
+	if slotIndex <= 0 {
+		invalid.Add("slot_index", "must be positive")
+	}
+	if !isValidStatus(status) {
+		invalid.Add("status", "must be one of scheduled, cancelled, replaced")
 	}
 	if status != "cancelled" {
-		if courseCode == "" || startTime == nil || endTime == nil || venue == "" {
-			return ErrInvalidInput
+		const message = "is required unless status is cancelled"
+		if courseCode == "" {
+			invalid.Add("course_code", message)
 		}
-	}
-
-	user, err := s.identity.GetMe(ctx, requesterID)
-	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			return ErrNotFound
+		if startTime == nil {
+			invalid.Add("start_time", message)
 		}
-		if errors.Is(err, ErrUnauthorized) {
-			return ErrUnauthorized
+		if endTime == nil {
+			invalid.Add("end_time", message)
 		}
-		return err
+		if venue == "" {
+			invalid.Add("venue", message)
+		}
+	}
+	if err := invalid.Err(); err != nil {
+		return 0, err
+	}
+	if err := validateOptionalTimeRange(startTime, endTime); err != nil {
+		return 0, err
 	}
 
-	if !isAuthorized(user, classID) {
-		return ErrUnauthorized
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return 0, err
 	}
 
 	localDate := truncateToDateLocal(date)
@@ -119,28 +166,118 @@ func (s *TimetableService) CreateDailyOverride(
 		StartTime:  startTime,
 		EndTime:    endTime,
 		Venue:      venue,
+		FacultyID:  facultyID,
 		Status:     status,
 	}
 
-	return s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+	var dayVersion int64
+	var written bool
+	err = s.withIdempotentTx(ctx, requesterID, &dayVersion, func(ctx context.Context, repos repository.TxRepositories) error {
+		// The day's version includes the revision of its weekday's defaults,
+		// which stay share-locked so they cannot change before commit.
+		revision, err := repos.Revisions.Share(ctx, classID, weekdayNumber(localDate))
+		if err != nil {
+			return err
+		}
+		// Bumping the day version first locks the day row, which serializes
+		// concurrent writers for the overlap check below.
+		if expectedVersion != nil {
+			if *expectedVersion < revision {
+				return ErrVersionMismatch
+			}
+			version, matched, err := repos.DayVersions.BumpIfMatch(ctx, classID, localDate, *expectedVersion-revision)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				return ErrVersionMismatch
+			}
+			dayVersion = version + revision
+		} else {
+			version, err := repos.DayVersions.Bump(ctx, classID, localDate)
+			if err != nil {
+				return err
+			}
+			dayVersion = version + revision
+		}
+
+		day, err := s.resolveTimetableWithRepos(ctx, repos, classID, localDate)
+		if err != nil {
+			return err
+		}
+		// Both the course being replaced and the one put in its place must be
+		// within the requester's reach.
+		resource := Resource{ClassID: classID, CourseCodes: []string{courseCode}}
+		for _, existing := range day {
+			if existing.SlotIndex == slotIndex {
+				resource.CourseCodes = append(resource.CourseCodes, existing.CourseCode)
+			}
+		}
+		if err := s.authorizeOverride(ctx, repos, user, resource, localDate); err != nil {
+			return err
+		}
+		if err := s.checkDayLocks(ctx, repos, user, resource, localDate, slotIndex); err != nil {
+			return err
+		}
+		bypassed, err := s.checkEditCutoff(ctx, repos, user, resource, localDate, slotIndex, day, startTime, bypassReason)
+		if err != nil {
+			return err
+		}
+
+		slot, err := s.resolveSingleSlot(ctx, repos, classID, localDate, slotIndex, override)
+		if err != nil {
+			return err
+		}
+		if err := validateSlotPlacement(slot, day); err != nil {
+			return err
+		}
+
 		if err := repos.Overrides.Upsert(ctx, override); err != nil {
 			return err
 		}
 
+		details := map[string]any{
+			"slot_index":  slotIndex,
+			"course_code": courseCode,
+			"start_time":  formatOptionalTime(startTime),
+			"end_time":    formatOptionalTime(endTime),
+			"venue":       venue,
+			"faculty_id":  facultyID,
+			"status":      status,
+			"day_version": dayVersion,
+		}
+		if bypassed {
+			details["cutoff_bypass_reason"] = bypassReason
+		}
+		err = s.recordAudit(ctx, repos, requesterID, AuditOverrideUpsert, classID, &localDate, details)
+		if err != nil {
+			return err
+		}
+
 		settings, err := repos.Settings.GetByClassID(ctx, classID)
 		if err != nil && err != sql.ErrNoRows {
 			return err
 		}
-		if err == nil && shouldEmitLateUpdate(settings, localDate, s.clock()) {
-			slot, err := s.resolveSingleSlot(ctx, repos, classID, localDate, slotIndex, override)
+		var schedules []domain.AnnouncementSchedule
+		if err == nil {
+			if schedules, err = repos.Schedules.ListByClassID(ctx, classID); err != nil {
+				return err
+			}
+		}
+		if shouldEmitLateUpdate(schedules, localDate, s.clock()) {
+			updated, err := s.resolveTimetableWithRepos(ctx, repos, classID, localDate)
 			if err != nil {
 				return err
 			}
+			templates := templatesByLocale(settings.Locale, settings.UpdateTemplate, settings.LocalizedUpdateTemplates)
+			messages := renderMessages(ctx, classID, templates, localDate, updated, []domain.Slot{slot}, requesterID.String())
 			payload := domain.TimetableUpdatedPayload{
 				ClassID:        classID.String(),
 				Date:           localDate.Format("2006-01-02"),
 				MatrixRoomID:   settings.MatrixRoomID,
 				UpdateTemplate: settings.UpdateTemplate,
+				Message:        messages[settings.Locale],
+				Messages:       messages,
 				Slots:          []domain.TimetableSlotPayload{slotToPayload(slot)},
 				UpdatedBy:      requesterID.String(),
 			}
@@ -155,8 +292,25 @@ func (s *TimetableService) CreateDailyOverride(
 			}
 		}
 
+		written = true
 		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+	// Replayed idempotent requests do not run the write and are not counted.
+	if written {
+		metrics.OverrideWritten(status)
+		slog.InfoContext(ctx, "override written",
+			"requester_id", requesterID,
+			"class_id", classID,
+			"date", localDate.Format("2006-01-02"),
+			"slot_index", slotIndex,
+			"status", status,
+			"day_version", dayVersion,
+		)
+	}
+	return dayVersion, nil
 }
 
 func (s *TimetableService) ResolveTimetable(ctx context.Context, classID uuid.UUID, date time.Time) ([]domain.Slot, error) {
@@ -172,25 +326,85 @@ func (s *TimetableService) ResolveTimetable(ctx context.Context, classID uuid.UU
 	return resolved, err
 }
 
-func (s *TimetableService) EmitDailyAnnouncementIfDue(ctx context.Context, now time.Time) error {
-	var settings []domain.AnnouncementSettings
+func (s *TimetableService) GetResolvedDay(ctx context.Context, classID uuid.UUID, date time.Time) (domain.ResolvedDay, error) {
+	if err := authorizeTimetableRead(ctx); err != nil {
+		return domain.ResolvedDay{}, err
+	}
+
+	localDate := truncateToDateLocal(date)
+	day := domain.ResolvedDay{ClassID: classID, Date: localDate}
 	err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		version, err := s.dayVersion(ctx, repos, classID, localDate)
+		if err != nil {
+			return err
+		}
+		slots, err := s.resolveTimetableWithRepos(ctx, repos, classID, localDate)
+		if err != nil {
+			return err
+		}
+		locks, err := repos.DayLocks.ListByDate(ctx, classID, localDate)
+		if err != nil {
+			return err
+		}
+		day.Version = version
+		day.Slots = slots
+		day.Locks = locks
+		return nil
+	})
+	return day, err
+}
+
+func (s *TimetableService) GetTodayResolvedDay(ctx context.Context, classID uuid.UUID) (domain.ResolvedDay, error) {
+	return s.GetResolvedDay(ctx, classID, s.clock())
+}
+
+// EmitDueAnnouncements writes an event for every announcement schedule whose
+// time has come today and which has not announced its date yet. Schedules
+// announcing the current day emit DailyTimetableAnnounced, schedules looking
+// ahead emit TimetablePreviewAnnounced.
+func (s *TimetableService) EmitDueAnnouncements(ctx context.Context, now time.Time) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "announcement.tick")
+	start := time.Now()
+	announced := 0
+	defer func() {
+		metrics.ObserveAnnouncementTick(time.Since(start), announced, err != nil)
+		span.SetAttributes(attribute.Int("announcement.classes_announced", announced))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	var settings []domain.AnnouncementSettings
+	var schedules []domain.AnnouncementSchedule
+	err = s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
 		var err error
-		settings, err = repos.Settings.ListAll(ctx)
+		if settings, err = repos.Settings.ListAll(ctx); err != nil {
+			return err
+		}
+		schedules, err = repos.Schedules.ListAll(ctx)
 		return err
 	})
 	if err != nil {
 		return err
 	}
 
+	byClass := make(map[uuid.UUID]domain.AnnouncementSettings, len(settings))
 	for _, setting := range settings {
-		if !isAnnouncementDue(setting, now) {
+		byClass[setting.ClassID] = setting
+	}
+
+	for _, schedule := range schedules {
+		date, due := announcementDue(schedule, now)
+		if !due {
 			continue
 		}
+		setting := byClass[schedule.ClassID]
 
-		date := truncateToDateLocal(now)
+		var emitted bool
 		err := s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
-			marked, err := repos.Settings.MarkAnnounced(ctx, setting.ClassID, date)
+			marked, err := repos.Schedules.MarkAnnounced(ctx, schedule.ClassID, schedule.Name, date)
 			if err != nil {
 				return err
 			}
@@ -198,34 +412,75 @@ func (s *TimetableService) EmitDailyAnnouncementIfDue(ctx context.Context, now t
 				return nil
 			}
 
-			resolved, err := s.resolveTimetableWithRepos(ctx, repos, setting.ClassID, date)
+			resolved, err := s.resolveTimetableWithRepos(ctx, repos, schedule.ClassID, date)
 			if err != nil {
 				return err
 			}
 
-			payload := domain.DailyTimetableAnnouncedPayload{
-				ClassID:      setting.ClassID.String(),
-				Date:         date.Format("2006-01-02"),
-				MatrixRoomID: setting.MatrixRoomID,
-				Template:     setting.DailyTemplate,
-				Slots:        slotsToPayloads(resolved),
-			}
-
-			event := domain.TimetableEvent{
-				EventType: "DailyTimetableAnnounced",
-				Payload:   payload,
+			templates := templatesByLocale(setting.Locale, schedule.Template, schedule.LocalizedTemplates)
+			messages := renderMessages(ctx, schedule.ClassID, templates, date, resolved, changedSlots(resolved), "")
+			if err := repos.Outbox.Insert(ctx, announcementEvent(setting, schedule, date, now, resolved, messages)); err != nil {
+				return err
 			}
-
-			return repos.Outbox.Insert(ctx, event)
+			emitted = true
+			return nil
 		})
 		if err != nil {
 			return err
 		}
+		if emitted {
+			announced++
+			slog.InfoContext(ctx, "timetable announced",
+				"class_id", schedule.ClassID,
+				"schedule", schedule.Name,
+				"date", date.Format("2006-01-02"),
+			)
+		}
 	}
 
 	return nil
 }
 
+func announcementEvent(
+	setting domain.AnnouncementSettings,
+	schedule domain.AnnouncementSchedule,
+	date time.Time,
+	now time.Time,
+	slots []domain.Slot,
+	messages map[string]string,
+) domain.TimetableEvent {
+	if schedule.DaysAhead == 0 {
+		return domain.TimetableEvent{
+			EventType: "DailyTimetableAnnounced",
+			Payload: domain.DailyTimetableAnnouncedPayload{
+				ClassID:      schedule.ClassID.String(),
+				Date:         date.Format("2006-01-02"),
+				Schedule:     schedule.Name,
+				MatrixRoomID: setting.MatrixRoomID,
+				Template:     schedule.Template,
+				Message:      messages[setting.Locale],
+				Messages:     messages,
+				Slots:        slotsToPayloads(slots),
+			},
+		}
+	}
+	return domain.TimetableEvent{
+		EventType: "TimetablePreviewAnnounced",
+		Payload: domain.TimetablePreviewAnnouncedPayload{
+			ClassID:      schedule.ClassID.String(),
+			Date:         date.Format("2006-01-02"),
+			AnnouncedOn:  truncateToDateLocal(now).Format("2006-01-02"),
+			DaysAhead:    schedule.DaysAhead,
+			Schedule:     schedule.Name,
+			MatrixRoomID: setting.MatrixRoomID,
+			Template:     schedule.Template,
+			Message:      messages[setting.Locale],
+			Messages:     messages,
+			Slots:        slotsToPayloads(slots),
+		},
+	}
+}
+
 func (s *TimetableService) resolveTimetableWithRepos(
 	ctx context.Context,
 	repos repository.TxRepositories,
@@ -243,22 +498,9 @@ func (s *TimetableService) resolveTimetableWithRepos(
 		return nil, err
 	}
 
-	baseSlots := make([]domain.Slot, 0, len(defaults))
-	for idx, def := range defaults {
-		slot := domain.Slot{
-			SlotIndex:  idx + 1,
-			CourseCode: def.CourseCode,
-			StartTime:  def.StartTime,
-			EndTime:    def.EndTime,
-			Venue:      def.Venue,
-			Status:     "scheduled",
-		}
-		baseSlots = append(baseSlots, slot)
-	}
-
-	byIndex := make(map[int]domain.Slot, len(baseSlots))
-	for _, slot := range baseSlots {
-		byIndex[slot.SlotIndex] = slot
+	byIndex := make(map[int]domain.Slot, len(defaults))
+	for _, def := range defaults {
+		byIndex[def.SlotIndex] = defaultToSlot(def)
 	}
 
 	for _, override := range overrides {
@@ -266,20 +508,9 @@ func (s *TimetableService) resolveTimetableWithRepos(
 		byIndex[override.SlotIndex] = resolved
 	}
 
-	maxIndex := len(baseSlots)
-	for _, override := range overrides {
-		if override.SlotIndex > maxIndex {
-			maxIndex = override.SlotIndex
-		}
-	}
-
-	resolved := make([]domain.Slot, 0, maxIndex)
-	for i := 1; i <= maxIndex; i++ {
-		slot, ok := byIndex[i]
-		if !ok {
-			continue
-		}
-		resolved = append(resolved, slot)
+	resolved := make([]domain.Slot, 0, len(byIndex))
+	for _, slotIndex := range slices.Sorted(maps.Keys(byIndex)) {
+		resolved = append(resolved, byIndex[slotIndex])
 	}
 
 	return resolved, nil
@@ -300,15 +531,9 @@ func (s *TimetableService) resolveSingleSlot(
 	}
 
 	var base domain.Slot
-	if slotIndex > 0 && slotIndex <= len(defaults) {
-		def := defaults[slotIndex-1]
-		base = domain.Slot{
-			SlotIndex:  slotIndex,
-			CourseCode: def.CourseCode,
-			StartTime:  def.StartTime,
-			EndTime:    def.EndTime,
-			Venue:      def.Venue,
-			Status:     "scheduled",
+	for _, def := range defaults {
+		if def.SlotIndex == slotIndex {
+			base = defaultToSlot(def)
 		}
 	}
 
@@ -320,20 +545,6 @@ func (s *TimetableService) resolveSingleSlot(
 	return resolved, nil
 }
 
-func isAuthorized(user IdentityUser, classID uuid.UUID) bool {
-	for _, role := range user.Roles {
-		switch role.Name {
-		case "faculty":
-			return true
-		case "cr":
-			if role.ClassID != nil && *role.ClassID == classID {
-				return true
-			}
-		}
-	}
-	return false
-}
-
 func isValidStatus(status string) bool {
 	switch status {
 	case "scheduled", "cancelled", "replaced":
@@ -360,6 +571,8 @@ func applyOverride(base domain.Slot, override domain.DailyOverride) domain.Slot
 	resolved := base
 	resolved.SlotIndex = override.SlotIndex
 	resolved.Status = override.Status
+	resolved.Version = override.Version
+	resolved.Overridden = true
 	if override.Status == "cancelled" {
 		if resolved.CourseCode == "" {
 			resolved.CourseCode = override.CourseCode
@@ -367,6 +580,9 @@ func applyOverride(base domain.Slot, override domain.DailyOverride) domain.Slot
 		if resolved.Venue == "" {
 			resolved.Venue = override.Venue
 		}
+		if resolved.FacultyID == nil {
+			resolved.FacultyID = override.FacultyID
+		}
 		if override.StartTime != nil {
 			resolved.StartTime = *override.StartTime
 		}
@@ -376,6 +592,12 @@ func applyOverride(base domain.Slot, override domain.DailyOverride) domain.Slot
 		return resolved
 	}
 
+	// The default slot's faculty only carries over while the course does.
+	if override.FacultyID != nil {
+		resolved.FacultyID = override.FacultyID
+	} else if override.CourseCode != base.CourseCode {
+		resolved.FacultyID = nil
+	}
 	resolved.CourseCode = override.CourseCode
 	if override.StartTime != nil {
 		resolved.StartTime = *override.StartTime
@@ -396,10 +618,18 @@ func slotToPayload(slot domain.Slot) domain.TimetableSlotPayload {
 		StartTime:  formatTime(slot.StartTime),
 		EndTime:    formatTime(slot.EndTime),
 		Venue:      slot.Venue,
+		FacultyID:  formatOptionalUUID(slot.FacultyID),
 		Status:     slot.Status,
 	}
 }
 
+func formatOptionalUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
 func slotsToPayloads(slots []domain.Slot) []domain.TimetableSlotPayload {
 	result := make([]domain.TimetableSlotPayload, 0, len(slots))
 	for _, slot := range slots {
@@ -408,6 +638,13 @@ func slotsToPayloads(slots []domain.Slot) []domain.TimetableSlotPayload {
 	return result
 }
 
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
 func formatTime(t time.Time) string {
 	if t.IsZero() {
 		return ""
@@ -415,45 +652,62 @@ func formatTime(t time.Time) string {
 	return t.Format("15:04")
 }
 
-func isAnnouncementDue(setting domain.AnnouncementSettings, now time.Time) bool {
+// announcementDue returns the date the schedule announces today and whether
+// it is due, i.e. its time has passed and it has not announced that date.
+func announcementDue(schedule domain.AnnouncementSchedule, now time.Time) (time.Time, bool) {
 	localNow := now.In(time.Local)
 	announceAt := time.Date(
 		localNow.Year(),
 		localNow.Month(),
 		localNow.Day(),
-		setting.DailyAnnounceTime.Hour(),
-		setting.DailyAnnounceTime.Minute(),
-		setting.DailyAnnounceTime.Second(),
+		schedule.AnnounceTime.Hour(),
+		schedule.AnnounceTime.Minute(),
+		schedule.AnnounceTime.Second(),
 		0,
 		localNow.Location(),
 	)
+	date := truncateToDateLocal(localNow).AddDate(0, 0, schedule.DaysAhead)
 
 	if localNow.Before(announceAt) {
-		return false
+		return date, false
 	}
-	if setting.LastAnnouncedDate == nil {
-		return true
+	if schedule.LastAnnouncedDate == nil {
+		return date, true
 	}
-	return truncateToDateLocal(*setting.LastAnnouncedDate).Before(truncateToDateLocal(localNow))
+	return date, truncateToDateLocal(*schedule.LastAnnouncedDate).Before(date)
 }
 
-func shouldEmitLateUpdate(setting domain.AnnouncementSettings, date time.Time, now time.Time) bool {
-	if setting.LastAnnouncedDate == nil {
-		return false
+// shouldEmitLateUpdate reports whether date was already announced by one of
+// the class's schedules, in which case changes to it are announced as well.
+// A schedule running daily has announced every date from today up to its
+// last announced date; days before today are no longer of interest unless
+// they are the last announced date itself.
+func shouldEmitLateUpdate(schedules []domain.AnnouncementSchedule, date time.Time, now time.Time) bool {
+	date = truncateToDateLocal(date)
+	today := truncateToDateLocal(now)
+	for _, schedule := range schedules {
+		if schedule.LastAnnouncedDate == nil {
+			continue
+		}
+		last := truncateToDateLocal(*schedule.LastAnnouncedDate)
+		if date.Equal(last) || (!date.Before(today) && !date.After(last)) {
+			return true
+		}
 	}
-	if truncateToDateLocal(*setting.LastAnnouncedDate) != truncateToDateLocal(date) {
-		return false
+	return false
+}
+
+// dayVersion is the override version of a day plus the revision of its
+// weekday's defaults. Both only grow, so a change to either changes the
+// version and the day's ETag.
+func (s *TimetableService) dayVersion(ctx context.Context, repos repository.TxRepositories, classID uuid.UUID, date time.Time) (int64, error) {
+	version, err := repos.DayVersions.Get(ctx, classID, date)
+	if err != nil {
+		return 0, err
 	}
-	localNow := now.In(time.Local)
-	announceAt := time.Date(
-		localNow.Year(),
-		localNow.Month(),
-		localNow.Day(),
-		setting.DailyAnnounceTime.Hour(),
-		setting.DailyAnnounceTime.Minute(),
-		setting.DailyAnnounceTime.Second(),
-		0,
-		localNow.Location(),
-	)
-	return localNow.After(announceAt)
+	revision, err := repos.Revisions.Get(ctx, classID, weekdayNumber(date))
+	if err != nil {
+		return 0, err
+	}
+	return version + revision, nil
 }
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"service-timetable/internal/domain"
+)
+
+func TestShouldEmitLateUpdate(t *testing.T) {
+	now := time.Date(2026, 10, 19, 18, 0, 0, 0, time.Local)
+	day := func(offset int) time.Time {
+		return time.Date(2026, 10, 19+offset, 0, 0, 0, 0, time.Local)
+	}
+	announced := func(offset int) []domain.AnnouncementSchedule {
+		last := day(offset)
+		return []domain.AnnouncementSchedule{{Name: "evening", DaysAhead: 1, LastAnnouncedDate: &last}}
+	}
+
+	tests := []struct {
+		name      string
+		schedules []domain.AnnouncementSchedule
+		date      time.Time
+		want      bool
+	}{
+		{name: "past day", schedules: announced(1), date: day(-1), want: false},
+		{name: "today within the announced window", schedules: announced(1), date: day(0), want: true},
+		{name: "announced day", schedules: announced(1), date: day(1), want: true},
+		{name: "future day", schedules: announced(1), date: day(2), want: false},
+		{name: "announced day in the past", schedules: announced(-2), date: day(-2), want: true},
+		{name: "day after a past announced day", schedules: announced(-2), date: day(-1), want: false},
+		{name: "never announced", schedules: []domain.AnnouncementSchedule{{Name: "evening", DaysAhead: 1}}, date: day(0), want: false},
+		{name: "no schedules", date: day(0), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldEmitLateUpdate(tt.schedules, tt.date, now); got != tt.want {
+				t.Errorf("shouldEmitLateUpdate(%s) = %v, want %v", tt.date.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"service-timetable/internal/domain"
+	"service-timetable/internal/repository"
+)
+
+func (s *TimetableService) GetAnnouncementSettings(ctx context.Context, requesterID uuid.UUID, classID uuid.UUID) (domain.AnnouncementSettings, error) {
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return domain.AnnouncementSettings{}, err
+	}
+	if err := s.authorize(user, CapManageSettings, Resource{ClassID: classID}); err != nil {
+		return domain.AnnouncementSettings{}, err
+	}
+
+	var settings domain.AnnouncementSettings
+	err = s.txManager.WithTx(ctx, func(ctx context.Context, repos repository.TxRepositories) error {
+		var err error
+		settings, err = repos.Settings.GetByClassID(ctx, classID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		settings.Schedules, err = repos.Schedules.ListByClassID(ctx, classID)
+		return err
+	})
+	return settings, err
+}
+
+const (
+	maxScheduleNameLength = 64
+	maxScheduleDaysAhead  = 7
+)
+
+// UpdateAnnouncementSettings replaces the settings of a class together with
+// all of its announcement schedules. An empty locale means defaultLocale.
+func (s *TimetableService) UpdateAnnouncementSettings(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	matrixRoomID string,
+	locale string,
+	updateTemplate string,
+	localizedUpdateTemplates map[string]string,
+	reminderMinutes *int,
+	reminderChangedOnly bool,
+	schedules []domain.AnnouncementSchedule,
+) error {
+	return s.idempotent(ctx, requesterID, nil, func(ctx context.Context) error {
+		return s.updateAnnouncementSettings(ctx, requesterID, classID, matrixRoomID, locale, updateTemplate, localizedUpdateTemplates, reminderMinutes, reminderChangedOnly, schedules)
+	})
+}
+
+func (s *TimetableService) updateAnnouncementSettings(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	matrixRoomID string,
+	locale string,
+	updateTemplate string,
+	localizedUpdateTemplates map[string]string,
+	reminderMinutes *int,
+	reminderChangedOnly bool,
+	schedules []domain.AnnouncementSchedule,
+) error {
+	// Templates are parsed and executed while validating, so only requesters
+	// who may change the settings get that far.
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(user, CapManageSettings, Resource{ClassID: classID}); err != nil {
+		return err
+	}
+
+	var invalid ValidationError
+	if matrixRoomID == "" {
+		invalid.Add("matrix_room_id", "is required")
+	}
+	if locale == "" {
+		locale = defaultLocale
+	}
+	if !validLocale(locale) {
+		invalid.Add("locale", unsupportedLocaleMessage())
+	}
+	if problem := validateTemplate(ctx, updateTemplate); problem != "" {
+		invalid.Add("update_template", problem)
+	}
+	validateLocalizedTemplates(ctx, &invalid, "localized_update_templates", locale, localizedUpdateTemplates)
+	if reminderMinutes != nil && (*reminderMinutes < 1 || *reminderMinutes > maxReminderMinutes) {
+		invalid.Add("reminder_minutes", fmt.Sprintf("must be between 1 and %d", maxReminderMinutes))
+	}
+	if reminderChangedOnly && reminderMinutes == nil {
+		invalid.Add("reminder_changed_only", "requires reminder_minutes")
+	}
+	names := make(map[string]bool, len(schedules))
+	for i, schedule := range schedules {
+		field := fmt.Sprintf("schedules[%d]", i)
+		switch {
+		case schedule.Name == "":
+			invalid.Add(field+".name", "is required")
+		case len(schedule.Name) > maxScheduleNameLength:
+			invalid.Add(field+".name", fmt.Sprintf("must be at most %d characters", maxScheduleNameLength))
+		case names[schedule.Name]:
+			invalid.Add(field+".name", "must be unique")
+		}
+		names[schedule.Name] = true
+		if schedule.DaysAhead < 0 || schedule.DaysAhead > maxScheduleDaysAhead {
+			invalid.Add(field+".days_ahead", fmt.Sprintf("must be between 0 and %d", maxScheduleDaysAhead))
+		}
+		if problem := validateTemplate(ctx, schedule.Template); problem != "" {
+			invalid.Add(field+".template", problem)
+		}
+		validateLocalizedTemplates(ctx, &invalid, field+".localized_templates", locale, schedule.LocalizedTemplates)
+	}
+	if err := invalid.Err(); err != nil {
+		return err
+	}
+
+	settings := domain.AnnouncementSettings{
+		ClassID:                  classID,
+		MatrixRoomID:             matrixRoomID,
+		Locale:                   locale,
+		UpdateTemplate:           updateTemplate,
+		LocalizedUpdateTemplates: localizedUpdateTemplates,
+		ReminderMinutes:          reminderMinutes,
+		ReminderChangedOnly:      reminderChangedOnly,
+	}
+	auditSchedules := make([]map[string]any, 0, len(schedules))
+	for _, schedule := range schedules {
+		auditSchedules = append(auditSchedules, map[string]any{
+			"name":                schedule.Name,
+			"days_ahead":          schedule.DaysAhead,
+			"announce_time":       formatTime(schedule.AnnounceTime),
+			"template":            schedule.Template,
+			"localized_templates": schedule.LocalizedTemplates,
+		})
+	}
+
+	return s.withIdempotentTx(ctx, requesterID, nil, func(ctx context.Context, repos repository.TxRepositories) error {
+		if err := repos.Settings.Upsert(ctx, settings); err != nil {
+			return err
+		}
+		if err := repos.Schedules.Replace(ctx, classID, schedules); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, repos, requesterID, AuditSettingsUpdate, classID, nil, map[string]any{
+			"matrix_room_id":             matrixRoomID,
+			"locale":                     locale,
+			"update_template":            updateTemplate,
+			"localized_update_templates": localizedUpdateTemplates,
+			"reminder_minutes":           reminderMinutes,
+			"reminder_changed_only":      reminderChangedOnly,
+			"schedules":                  auditSchedules,
+		})
+	})
+}
+
+// PreviewTemplate renders text in locale against the resolved timetable of
+// the class on date, with the requester as updated_by. An empty locale means
+// defaultLocale.
+func (s *TimetableService) PreviewTemplate(
+	ctx context.Context,
+	requesterID uuid.UUID,
+	classID uuid.UUID,
+	date time.Time,
+	locale string,
+	text string,
+) (string, error) {
+	user, err := s.lookupRequester(ctx, requesterID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.authorize(user, CapManageSettings, Resource{ClassID: classID}); err != nil {
+		return "", err
+	}
+
+	if locale == "" {
+		locale = defaultLocale
+	}
+	var invalid ValidationError
+	if !validLocale(locale) {
+		invalid.Add("locale", unsupportedLocaleMessage())
+	}
+	if problem := validateTemplate(ctx, text); problem != "" {
+		invalid.Add("template", problem)
+	}
+	if err := invalid.Err(); err != nil {
+		return "", err
+	}
+
+	localDate := truncateToDateLocal(date)
+	slots, err := s.ResolveTimetable(ctx, classID, localDate)
+	if err != nil {
+		return "", err
+	}
+	message, err := renderTemplate(ctx, text, templateData(locale, localDate, slots, changedSlots(slots), requesterID.String()))
+	if err != nil {
+		return "", invalidField("template", "could not be rendered: "+err.Error())
+	}
+	return message, nil
+}
+
+// validateLocalizedTemplates checks templates keyed by locale, which must be
+// supported locales other than the primary one.
+func validateLocalizedTemplates(ctx context.Context, invalid *ValidationError, field string, primary string, templates map[string]string) {
+	for _, locale := range slices.Sorted(maps.Keys(templates)) {
+		switch {
+		case !validLocale(locale):
+			invalid.Add(field+"."+locale, "locale "+unsupportedLocaleMessage())
+		case locale == primary:
+			invalid.Add(field+"."+locale, "must not repeat the primary locale")
+		default:
+			if problem := validateTemplate(ctx, templates[locale]); problem != "" {
+				invalid.Add(field+"."+locale, problem)
+			}
+		}
+	}
+}
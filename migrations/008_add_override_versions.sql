@@ -0,0 +1,10 @@
+ALTER TABLE timetable.daily_overrides
+    ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS timetable.day_versions (
+    class_id uuid NOT NULL,
+    date date NOT NULL,
+    version bigint NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (class_id, date)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS timetable.idempotency_keys (
+    requester_id uuid NOT NULL,
+    key text NOT NULL,
+    fingerprint text NOT NULL,
+    response jsonb NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (requester_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx
+    ON timetable.idempotency_keys (created_at);
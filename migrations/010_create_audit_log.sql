@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS timetable.audit_log (
+    id uuid PRIMARY KEY,
+    occurred_at timestamptz NOT NULL DEFAULT now(),
+    actor_id uuid NOT NULL,
+    action text NOT NULL,
+    class_id uuid NOT NULL,
+    date date NULL,
+    details jsonb NOT NULL DEFAULT '{}'::jsonb
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_class_occurred_idx
+    ON timetable.audit_log (class_id, occurred_at DESC);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS timetable.delegations (
+    id uuid PRIMARY KEY,
+    class_id uuid NOT NULL,
+    delegate_id uuid NOT NULL,
+    granted_by uuid NOT NULL,
+    starts_on date NOT NULL,
+    ends_on date NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    revoked_at timestamptz NULL,
+    revoked_by uuid NULL,
+    CHECK (ends_on >= starts_on)
+);
+
+CREATE INDEX IF NOT EXISTS delegations_delegate_class_idx
+    ON timetable.delegations (delegate_id, class_id)
+    WHERE revoked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS delegations_class_idx
+    ON timetable.delegations (class_id, ends_on);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS timetable.day_locks (
+    class_id uuid NOT NULL,
+    date date NOT NULL,
+    slot_index integer NOT NULL DEFAULT 0,
+    locked_by uuid NOT NULL,
+    locked_at timestamptz NOT NULL DEFAULT now(),
+    reason text NOT NULL DEFAULT '',
+    PRIMARY KEY (class_id, date, slot_index),
+    CHECK (slot_index >= 0)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS timetable.edit_policies (
+    class_id uuid PRIMARY KEY,
+    cutoff_minutes integer NULL,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CHECK (cutoff_minutes IS NULL OR cutoff_minutes >= 0)
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS timetable.api_keys (
+    id uuid PRIMARY KEY,
+    name text NOT NULL,
+    key_hash bytea NOT NULL UNIQUE,
+    key_prefix text NOT NULL,
+    scopes jsonb NOT NULL,
+    class_ids jsonb NOT NULL DEFAULT '[]'::jsonb,
+    created_by uuid NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NULL,
+    revoked_at timestamptz NULL,
+    last_used_at timestamptz NULL
+);
+
+ALTER TABLE timetable.audit_log
+    ALTER COLUMN class_id DROP NOT NULL;
+
+ALTER TABLE timetable.audit_log
+    ADD COLUMN IF NOT EXISTS actor_kind text NOT NULL DEFAULT 'user';
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS timetable.leader_leases (
+    name text PRIMARY KEY,
+    holder text NOT NULL,
+    acquired_at timestamptz NOT NULL,
+    renewed_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
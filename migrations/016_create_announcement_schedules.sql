@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS timetable.announcement_schedules (
+    class_id uuid NOT NULL REFERENCES timetable.announcement_settings (class_id) ON DELETE CASCADE,
+    name text NOT NULL,
+    days_ahead smallint NOT NULL,
+    announce_time time NOT NULL,
+    template text NOT NULL,
+    last_announced_date date NULL,
+    PRIMARY KEY (class_id, name),
+    CHECK (days_ahead BETWEEN 0 AND 7)
+);
+
+INSERT INTO timetable.announcement_schedules (class_id, name, days_ahead, announce_time, template, last_announced_date)
+SELECT class_id, 'daily', 0, daily_announce_time, daily_template, last_announced_date
+FROM timetable.announcement_settings
+ON CONFLICT (class_id, name) DO NOTHING;
+
+ALTER TABLE timetable.announcement_settings
+    DROP COLUMN IF EXISTS daily_announce_time,
+    DROP COLUMN IF EXISTS daily_template,
+    DROP COLUMN IF EXISTS last_announced_date;
@@ -0,0 +1,11 @@
+ALTER TABLE timetable.announcement_settings
+    ADD COLUMN IF NOT EXISTS reminder_minutes integer NULL CHECK (reminder_minutes BETWEEN 1 AND 180),
+    ADD COLUMN IF NOT EXISTS reminder_changed_only boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS timetable.slot_reminders (
+    class_id uuid NOT NULL,
+    date date NOT NULL,
+    slot_index integer NOT NULL,
+    reminded_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (class_id, date, slot_index)
+);
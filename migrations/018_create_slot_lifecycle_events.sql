@@ -0,0 +1,14 @@
+ALTER TABLE timetable.default_slots
+    ADD COLUMN IF NOT EXISTS faculty_id uuid NULL;
+
+ALTER TABLE timetable.daily_overrides
+    ADD COLUMN IF NOT EXISTS faculty_id uuid NULL;
+
+CREATE TABLE IF NOT EXISTS timetable.slot_lifecycle_events (
+    class_id uuid NOT NULL,
+    date date NOT NULL,
+    slot_index integer NOT NULL,
+    event_type text NOT NULL,
+    emitted_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (class_id, date, slot_index, event_type)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE timetable.announcement_settings
+    ADD COLUMN IF NOT EXISTS locale text NOT NULL DEFAULT 'en',
+    ADD COLUMN IF NOT EXISTS localized_update_templates jsonb NOT NULL DEFAULT '{}'::jsonb;
+
+ALTER TABLE timetable.announcement_schedules
+    ADD COLUMN IF NOT EXISTS localized_templates jsonb NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,37 @@
+ALTER TABLE timetable.default_slots
+    ADD COLUMN IF NOT EXISTS slot_index integer NULL;
+
+-- Existing defaults keep the positional indices overrides were written
+-- against.
+UPDATE timetable.default_slots AS d
+SET slot_index = numbered.slot_index
+FROM (
+    SELECT class_id, weekday, start_time,
+        row_number() OVER (PARTITION BY class_id, weekday ORDER BY start_time) AS slot_index
+    FROM timetable.default_slots
+) AS numbered
+WHERE d.slot_index IS NULL
+  AND d.class_id = numbered.class_id
+  AND d.weekday = numbered.weekday
+  AND d.start_time = numbered.start_time;
+
+ALTER TABLE timetable.default_slots
+    ALTER COLUMN slot_index SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS default_slots_class_weekday_slot_idx
+    ON timetable.default_slots (class_id, weekday, slot_index);
+
+CREATE TABLE IF NOT EXISTS timetable.default_revisions (
+    class_id uuid NOT NULL,
+    weekday integer NOT NULL,
+    revision bigint NOT NULL DEFAULT 0,
+    next_slot_index integer NOT NULL DEFAULT 1,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (class_id, weekday)
+);
+
+INSERT INTO timetable.default_revisions (class_id, weekday, next_slot_index)
+SELECT class_id, weekday, max(slot_index) + 1
+FROM timetable.default_slots
+GROUP BY class_id, weekday
+ON CONFLICT (class_id, weekday) DO NOTHING;
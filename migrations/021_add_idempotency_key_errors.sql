@@ -0,0 +1,4 @@
+-- Failed requests store the error they were answered with, so that a retry
+-- replays it instead of running the request again.
+ALTER TABLE timetable.idempotency_keys
+    ADD COLUMN IF NOT EXISTS error jsonb NULL;
@@ -0,0 +1,10 @@
+-- Reminders are recorded per start time, so that a slot moved to a later
+-- time after its reminder is reminded of again. Reminders recorded before
+-- carry midnight; they are purged within a week.
+ALTER TABLE timetable.slot_reminders
+    ADD COLUMN IF NOT EXISTS start_time time NOT NULL DEFAULT '00:00';
+
+ALTER TABLE timetable.slot_reminders
+    ALTER COLUMN start_time DROP DEFAULT,
+    DROP CONSTRAINT IF EXISTS slot_reminders_pkey,
+    ADD PRIMARY KEY (class_id, date, slot_index, start_time);
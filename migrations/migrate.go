@@ -1,6 +1,7 @@
 package migrations
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"errors"
@@ -75,6 +76,55 @@ func Up(db *sql.DB) error {
 	return nil
 }
 
+// Status returns the latest applied migration and the embedded migrations
+// that are not applied yet.
+func Status(ctx context.Context, db *sql.DB) (string, []string, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return "", nil, fmt.Errorf("list embedded migrations: %w", err)
+	}
+	sort.Strings(names)
+
+	rows, err := db.QueryContext(ctx, `SELECT filename FROM public.schema_migrations_timetable`)
+	if err != nil {
+		return "", nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", nil, err
+		}
+		applied[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, err
+	}
+
+	var current string
+	var pending []string
+	for _, name := range names {
+		if applied[name] {
+			current = name
+		} else {
+			pending = append(pending, name)
+		}
+	}
+	return current, pending, nil
+}
+
+// Expected returns the latest embedded migration.
+func Expected() string {
+	names, _ := fs.Glob(files, "*.sql")
+	sort.Strings(names)
+	if len(names) == 0 {
+		return ""
+	}
+	return names[len(names)-1]
+}
+
 func ensureMigrationsTable(db *sql.DB) error {
 	const query = `
 CREATE TABLE IF NOT EXISTS public.schema_migrations_timetable (